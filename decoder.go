@@ -0,0 +1,41 @@
+package env
+
+import "reflect"
+
+// Decoder unmarshals variables into new values of type T, which must be a
+// struct. Its options are configured once, and the tags and field types of T
+// are parsed and validated when it is created, so it can be reused cheaply,
+// including concurrently.
+type Decoder[T any] struct {
+	config decoder
+}
+
+// NewDecoder returns a Decoder for the struct type T with the given options.
+// It returns a *TagError for each unknown tag option and field of a type
+// that can't be unmarshalled, whether or not its variable is set.
+//
+//	dec, err := env.NewDecoder[Config](env.WithPrefix("APP_"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	cfg, err := dec.Decode()
+func NewDecoder[T any](opts ...Option) (*Decoder[T], error) {
+	t := typeOf[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, &InvalidUnmarshalError{Type: reflect.PointerTo(t)}
+	}
+	d := newDecoder(opts)
+	if err := d.validate(t); err != nil {
+		return nil, err
+	}
+	d.validated = true
+	return &Decoder[T]{config: *d}, nil
+}
+
+// Decode unmarshals variables into a new value of type T.
+func (dec *Decoder[T]) Decode() (T, error) {
+	var v T
+	d := dec.config
+	err := d.unmarshal(&v)
+	return v, err
+}
@@ -0,0 +1,119 @@
+package env
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	type Database struct {
+		Host string `cfg:"HOST,default=localhost"`
+		Port int    `cfg:"PORT,default=5432"`
+	}
+
+	type Config struct {
+		Name     string   `cfg:"NAME,required"`
+		Hosts    []string `cfg:"HOSTS"`
+		Tags     []string `cfg:"TAGS,sep=[,]"`
+		Database Database `cfg:"DB"`
+		Ignored  string   `env:"IGNORED"`
+	}
+
+	src := Map{
+		"APP_NAME":    "app",
+		"APP_HOSTS":   "a;b",
+		"APP_TAGS":    "x,y",
+		"APP_DB_HOST": "db.internal",
+		"IGNORED":     "ignored",
+	}
+
+	dec, err := NewDecoder[Config](WithSource(src), WithTagName("cfg"), WithPrefix("APP_"), WithSeparator(";"))
+	assertNoError(t, err, "NewDecoder")
+
+	want := Config{
+		Name:     "app",
+		Hosts:    []string{"a", "b"},
+		Tags:     []string{"x", "y"},
+		Database: Database{Host: "db.internal", Port: 5432},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := dec.Decode()
+			assertNoError(t, err, "Decode")
+			assertEqual(t, want, cfg, "Config")
+		}()
+	}
+	wg.Wait()
+
+	vars, err := Marshal(want, WithTagName("cfg"), WithPrefix("APP_"), WithSeparator(";"))
+	assertNoError(t, err, "Marshal")
+	assertEqual(t, "a;b", vars["APP_HOSTS"], "APP_HOSTS")
+	assertEqual(t, "x,y", vars["APP_TAGS"], "APP_TAGS")
+	assertEqual(t, "db.internal", vars["APP_DB_HOST"], "APP_DB_HOST")
+}
+
+func TestDecoderDecodeErrors(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME,required"`
+	}
+
+	dec, err := NewDecoder[Config](WithSource(Map{}))
+	assertNoError(t, err, "NewDecoder")
+
+	_, err = dec.Decode()
+	assertEqual(t, "required environment variable NAME is not set", err.Error(), "Decode")
+}
+
+func TestNewDecoderInvalid(t *testing.T) {
+	type Upstream struct {
+		Port int `env:"PORT,requried"`
+	}
+
+	type Config struct {
+		Name      string           `env:"NAME,defualt=x"`
+		Channel   chan int         `env:"CHANNEL"`
+		Nested    *struct{ A int } `env:"NESTED"`
+		Missing   string           `env:",required"`
+		Upstreams []Upstream       `env:"UPSTREAM"`
+		Valid     []int            `env:"VALID,sep=;,default=1;2"`
+		Untagged  chan int
+	}
+
+	_, err := NewDecoder[Config]()
+	assertError(t, err, "NewDecoder")
+
+	want := `invalid field Name: unknown option "defualt=x", did you mean "default"?` + "\n" +
+		"invalid field Channel: unsupported type chan int\n" +
+		"invalid field Nested: unsupported type *struct { A int }\n" +
+		"invalid field Missing: missing variable name\n" +
+		`invalid field Upstreams[<N>].Port: unknown option "requried", did you mean "required"?`
+	assertEqual(t, want, err.Error(), "error")
+
+	var tagErr *TagError
+	if !errors.As(err, &tagErr) {
+		t.Fatalf("expected *TagError, got %v", err)
+	}
+	assertEqual(t, "Name", tagErr.FieldPath, "FieldPath")
+
+	_, err = NewDecoder[int]()
+	assertEqual(t, "cannot unmarshal into non-struct *int", err.Error(), "non-struct")
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME,requird"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{"NAME": "app"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, "app", cfg.Name, "Name")
+
+	err = UnmarshalFrom(Map{"NAME": "app"}, &cfg, WithStrict())
+	assertEqual(t, `invalid field Name: unknown option "requird", did you mean "required"?`, err.Error(), "strict")
+}
@@ -0,0 +1,234 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// WriteMarkdown writes a Markdown table documenting the variables read by a
+// struct to w, listing the key, aliases, type, default, whether it is
+// required and the description of each. Defaults of secret fields are
+// replaced by a placeholder.
+func WriteMarkdown(w io.Writer, data interface{}) error {
+	t, err := structTypeOf(data)
+	if err != nil {
+		return fmt.Errorf("cannot write markdown: %w", err)
+	}
+
+	b := bufio.NewWriter(w)
+	b.WriteString("| Variable | Aliases | Type | Default | Required | Description |\n")
+	b.WriteString("| -------- | ------- | ---- | ------- | -------- | ----------- |\n")
+	for _, field := range newDecoder(nil).fields(t, "", "") {
+		aliases := make([]string, len(field.aliases))
+		for i, alias := range field.aliases {
+			aliases[i] = markdownCode(alias)
+		}
+
+		fallback := field.opts.fallback
+		if field.opts.secret && fallback != "" {
+			fallback = secretPlaceholder
+		}
+
+		required := ""
+		if field.opts.required {
+			required = "yes"
+		}
+
+		desc := field.opts.desc
+		if len(field.opts.enum) > 0 {
+			values := make([]string, len(field.opts.enum))
+			for i, value := range field.opts.enum {
+				values[i] = markdownCode(value)
+			}
+			if desc != "" {
+				desc += " "
+			}
+			desc += "One of " + strings.Join(values, ", ") + "."
+		}
+
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(field.key), strings.Join(aliases, ", "), markdownCode(field.typ.String()),
+			markdownCode(fallback), required, markdownEscape(desc))
+	}
+	return b.Flush()
+}
+
+// markdownCode formats a value as inline code in a table cell, or returns an
+// empty string if it is empty.
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + markdownEscape(value) + "`"
+}
+
+// markdownEscape escapes the characters that would end a table cell or row.
+func markdownEscape(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
+
+// jsonSchema is the subset of a JSON Schema document used to describe the
+// variables of a struct.
+type jsonSchema struct {
+	Schema            string                 `json:"$schema,omitempty"`
+	Title             string                 `json:"title,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	Type              string                 `json:"type,omitempty"`
+	GoType            string                 `json:"x-go-type,omitempty"`
+	Default           *string                `json:"default,omitempty"`
+	Enum              []string               `json:"enum,omitempty"`
+	Pattern           string                 `json:"pattern,omitempty"`
+	Format            string                 `json:"format,omitempty"`
+	MinLength         int                    `json:"minLength,omitempty"`
+	WriteOnly         bool                   `json:"writeOnly,omitempty"`
+	Properties        map[string]*jsonSchema `json:"properties,omitempty"`
+	PatternProperties map[string]*jsonSchema `json:"patternProperties,omitempty"`
+	Required          []string               `json:"required,omitempty"`
+	AllOf             []*jsonSchema          `json:"allOf,omitempty"`
+	AnyOf             []*jsonSchema          `json:"anyOf,omitempty"`
+}
+
+// WriteJSONSchema writes a JSON Schema document describing the variables read
+// by a struct to w. The schema describes an object of string values, such as
+// a parsed .env file, with a property for each key and alias including its
+// description, default, allowed values, and a pattern matching the format of
+// its type. Required variables without a default must be present and not
+// empty, where any alias satisfies the requirement. Fields of slices and maps
+// of structs are described by pattern properties.
+func WriteJSONSchema(w io.Writer, data interface{}) error {
+	t, err := structTypeOf(data)
+	if err != nil {
+		return fmt.Errorf("cannot write JSON schema: %w", err)
+	}
+
+	d := newDecoder(nil)
+	schema := &jsonSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      t.Name(),
+		Type:       "object",
+		Properties: map[string]*jsonSchema{},
+	}
+	for _, field := range d.fields(t, "", "") {
+		prop := d.propertySchema(field)
+
+		keys := append([]string{field.key}, field.aliases...)
+		if strings.Contains(field.key, indexPlaceholder) || strings.Contains(field.key, namePlaceholder) {
+			if schema.PatternProperties == nil {
+				schema.PatternProperties = map[string]*jsonSchema{}
+			}
+			for _, key := range keys {
+				schema.PatternProperties[keyPattern(key)] = prop
+			}
+			continue
+		}
+
+		for _, key := range keys {
+			schema.Properties[key] = prop
+		}
+		if !field.opts.required || field.opts.fallback != "" {
+			continue
+		}
+		if len(keys) == 1 {
+			schema.Required = append(schema.Required, field.key)
+			continue
+		}
+		anyOf := &jsonSchema{}
+		for _, key := range keys {
+			anyOf.AnyOf = append(anyOf.AnyOf, &jsonSchema{Required: []string{key}})
+		}
+		schema.AllOf = append(schema.AllOf, anyOf)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// propertySchema returns the schema of the value of a field's variable.
+func (d *decoder) propertySchema(field fieldInfo) *jsonSchema {
+	prop := &jsonSchema{
+		Type:        "string",
+		GoType:      field.typ.String(),
+		Description: field.opts.desc,
+		Enum:        field.opts.enum,
+		WriteOnly:   field.opts.secret,
+	}
+	if field.opts.fallback != "" && !field.opts.secret {
+		fallback := field.opts.fallback
+		prop.Default = &fallback
+	}
+	if field.opts.required && field.opts.fallback == "" {
+		prop.MinLength = 1
+	} else if len(prop.Enum) > 0 {
+		// Empty values are ignored by Unmarshal, so they are always allowed.
+		prop.Enum = append([]string{""}, prop.Enum...)
+	}
+	if field.opts.file {
+		return prop
+	}
+	if field.typ == timeType && field.opts.layout == "" {
+		prop.Format = "date-time"
+	} else if pattern := d.valuePattern(field.typ, field.opts); pattern != "" {
+		// Empty values are ignored by Unmarshal, so they always match.
+		prop.Pattern = "^(?:" + pattern + ")?$"
+	}
+	return prop
+}
+
+// Patterns matching the values accepted for the basic types.
+const (
+	boolPattern     = `[Tt][Rr][Uu][Ee]|[Ff][Aa][Ll][Ss][Ee]|[Yy][Ee][Ss]|[Nn][Oo]|0|1`
+	intPattern      = `[+-]?[0-9]+`
+	uintPattern     = `[0-9]+`
+	floatPattern    = `[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][+-]?[0-9]+)?`
+	durationPattern = `[+-]?(?:0|(?:(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:ns|us|µs|μs|ms|s|m|h|d|w))+)`
+)
+
+// valuePattern returns an unanchored regular expression matching the common
+// formats of the values accepted for the type, or an empty string if values
+// aren't restricted to a format that can be described by one.
+func (d *decoder) valuePattern(t reflect.Type, opts tagOptions) string {
+	if d.parser(t) != nil || isUnmarshaler(t) {
+		return ""
+	}
+	if t == durationType {
+		return durationPattern
+	}
+	if t == timeType || t == locationType {
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return boolPattern
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intPattern
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uintPattern
+	case reflect.Float32, reflect.Float64:
+		return floatPattern
+	case reflect.Pointer:
+		return d.valuePattern(t.Elem(), opts)
+	case reflect.Slice, reflect.Array:
+		elem := d.valuePattern(t.Elem(), opts)
+		if elem == "" {
+			return ""
+		}
+		return "(?:" + elem + ")(?:" + regexp.QuoteMeta(opts.separator()) + "(?:" + elem + "))*"
+	}
+	return ""
+}
+
+// keyPattern returns an anchored regular expression matching the keys of a
+// slice or map of structs, with the index and name placeholders replaced.
+func keyPattern(key string) string {
+	pattern := regexp.QuoteMeta(key)
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta(indexPlaceholder), "(?:0|[1-9][0-9]*)")
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta(namePlaceholder), ".+")
+	return "^" + pattern + "$"
+}
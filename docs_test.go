@@ -0,0 +1,110 @@
+package env
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type docsTestConfig struct {
+	Port     int           `env:"PORT|HTTP_PORT,default=8080,desc=Port to listen on"`
+	Level    string        `env:"LEVEL,enum=debug|info|error,default=info"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Started  time.Time     `env:"STARTED"`
+	Ratios   []float64     `env:"RATIOS,sep=;"`
+	Debug    bool          `env:"DEBUG"`
+	Token    string        `env:"TOKEN|API_TOKEN,required,secret,desc=[API token, a|b separated]"`
+	Database struct {
+		Host string `env:"HOST,required"`
+		Cert string `env:"CERT,file"`
+	} `env:"DB"`
+	Upstreams []struct {
+		Port uint16 `env:"PORT"`
+	} `env:"UPSTREAM"`
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	err := WriteMarkdown(&b, &docsTestConfig{})
+	assertNoError(t, err, "WriteMarkdown")
+
+	want := strings.Join([]string{
+		"| Variable | Aliases | Type | Default | Required | Description |",
+		"| -------- | ------- | ---- | ------- | -------- | ----------- |",
+		"| `PORT` | `HTTP_PORT` | `int` | `8080` |  | Port to listen on |",
+		"| `LEVEL` |  | `string` | `info` |  | One of `debug`, `info`, `error`. |",
+		"| `TIMEOUT` |  | `time.Duration` |  |  |  |",
+		"| `STARTED` |  | `time.Time` |  |  |  |",
+		"| `RATIOS` |  | `[]float64` |  |  |  |",
+		"| `DEBUG` |  | `bool` |  |  |  |",
+		"| `TOKEN` | `API_TOKEN` | `string` |  | yes | API token, a\\|b separated |",
+		"| `DB_HOST` |  | `string` |  | yes |  |",
+		"| `DB_CERT` |  | `string` |  |  |  |",
+		"| `UPSTREAM_<N>_PORT` |  | `uint16` |  |  |  |",
+		"",
+	}, "\n")
+	assertEqual(t, want, b.String(), "markdown")
+}
+
+func TestWriteJSONSchema(t *testing.T) {
+	var b strings.Builder
+	err := WriteJSONSchema(&b, docsTestConfig{})
+	assertNoError(t, err, "WriteJSONSchema")
+
+	var schema jsonSchema
+	err = json.Unmarshal([]byte(b.String()), &schema)
+	assertNoError(t, err, "json.Unmarshal")
+
+	assertEqual(t, "https://json-schema.org/draft/2020-12/schema", schema.Schema, "$schema")
+	assertEqual(t, "docsTestConfig", schema.Title, "title")
+	assertEqual(t, "object", schema.Type, "type")
+	assertEqual(t, []string{"DB_HOST"}, schema.Required, "required")
+	assertEqual(t, 1, len(schema.AllOf), "allOf")
+	assertEqual(t, []string{"TOKEN"}, schema.AllOf[0].AnyOf[0].Required, "allOf TOKEN")
+	assertEqual(t, []string{"API_TOKEN"}, schema.AllOf[0].AnyOf[1].Required, "allOf API_TOKEN")
+
+	port := schema.Properties["PORT"]
+	assertEqual(t, "string", port.Type, "PORT type")
+	assertEqual(t, "int", port.GoType, "PORT x-go-type")
+	assertEqual(t, "8080", *port.Default, "PORT default")
+	assertEqual(t, "Port to listen on", port.Description, "PORT description")
+	assertEqual(t, port, schema.Properties["HTTP_PORT"], "HTTP_PORT")
+
+	assertEqual(t, []string{"", "debug", "info", "error"}, schema.Properties["LEVEL"].Enum, "LEVEL enum")
+	assertEqual(t, "date-time", schema.Properties["STARTED"].Format, "STARTED format")
+	assertEqual(t, "", schema.Properties["DB_CERT"].Pattern, "DB_CERT pattern")
+
+	token := schema.Properties["TOKEN"]
+	assertEqual(t, true, token.WriteOnly, "TOKEN writeOnly")
+	assertEqual(t, 1, token.MinLength, "TOKEN minLength")
+
+	upstream, ok := schema.PatternProperties[`^UPSTREAM_(?:0|[1-9][0-9]*)_PORT$`]
+	assertEqual(t, true, ok, "UPSTREAM pattern property")
+	assertEqual(t, "uint16", upstream.GoType, "UPSTREAM x-go-type")
+
+	patterns := map[string]struct{ valid, invalid []string }{
+		"PORT":    {[]string{"", "8080", "-1", "+2"}, []string{"80a", "1.5"}},
+		"TIMEOUT": {[]string{"0", "1h30m", "1.5s", "2d", "-3w"}, []string{"5", "1x", "h"}},
+		"RATIOS":  {[]string{"0.5", "1;2.5;.5;1e-9"}, []string{"1,2", "1;", "x"}},
+		"DEBUG":   {[]string{"true", "FALSE", "Yes", "0"}, []string{"on", "2"}},
+	}
+	for key, tc := range patterns {
+		re := regexp.MustCompile(schema.Properties[key].Pattern)
+		for _, value := range tc.valid {
+			assertEqual(t, true, re.MatchString(value), key+" matches "+value)
+		}
+		for _, value := range tc.invalid {
+			assertEqual(t, false, re.MatchString(value), key+" matches "+value)
+		}
+	}
+}
+
+func TestDocsInvalid(t *testing.T) {
+	err := WriteMarkdown(&strings.Builder{}, 1)
+	assertEqual(t, "cannot write markdown: expected a struct or a pointer to one, got int", err.Error(), "WriteMarkdown")
+
+	err = WriteJSONSchema(&strings.Builder{}, 1)
+	assertEqual(t, "cannot write JSON schema: expected a struct or a pointer to one, got int", err.Error(), "WriteJSONSchema")
+}
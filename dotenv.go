@@ -0,0 +1,329 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError describes a malformed entry in a dotenv file.
+type SyntaxError struct {
+	File   string // name of the file, empty when parsed from a reader
+	Line   int    // 1-based line number
+	Column int    // 1-based column number, in runes
+	Msg    string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// Load reads the named dotenv files, or ".env" if none are given, and sets
+// each variable in the process environment unless it is already present.
+// Since loaded variables become present, earlier files take precedence over
+// later ones.
+func Load(paths ...string) error {
+	return load(false, paths)
+}
+
+// Overload reads the named dotenv files, or ".env" if none are given, and sets
+// each variable in the process environment, overriding any existing value.
+// Later files take precedence over earlier ones.
+func Overload(paths ...string) error {
+	return load(true, paths)
+}
+
+func load(override bool, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	for _, path := range paths {
+		vars, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		for _, key := range vars.Keys() {
+			if _, ok := Lookup(key); ok && !override {
+				continue
+			}
+			if err := Set(key, vars[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Read reads the named dotenv files, or ".env" if none are given, and returns
+// their variables as a Source without modifying the process environment.
+// Later files take precedence over earlier ones.
+func Read(paths ...string) (Map, error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	result := Map{}
+	for _, path := range paths {
+		vars, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range vars {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// Parse reads dotenv formatted variables from r.
+func Parse(r io.Reader) (Map, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotenv("", string(data))
+}
+
+func readFile(path string) (Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotenv(path, string(data))
+}
+
+// dotenvParser holds the state of a single dotenv document being parsed.
+type dotenvParser struct {
+	name string
+	src  string
+	pos  int
+}
+
+// parseDotenv parses a dotenv document. Each entry has the form
+// [export] KEY=VALUE, where the value is either unquoted, or wrapped in
+// single quotes, double quotes or backticks. Only double-quoted values
+// interpret escape sequences, and quoted values may span multiple lines.
+// Multi-line values may also be written as a heredoc in the format of GitHub
+// Actions environment files, KEY<<DELIMITER, followed by the lines of the
+// value and a line containing only the delimiter.
+func parseDotenv(name, src string) (Map, error) {
+	p := &dotenvParser{name: name, src: strings.ReplaceAll(src, "\r\n", "\n")}
+	vars := Map{}
+
+	for {
+		p.skip(" \t\n")
+		if p.eof() {
+			return vars, nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		if strings.HasPrefix(p.src[p.pos:], "export") && p.pos+6 < len(p.src) && isBlank(p.src[p.pos+6]) {
+			p.pos += 6
+			p.skip(" \t")
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skip(" \t")
+		if strings.HasPrefix(p.src[p.pos:], "<<") {
+			value, err := p.heredoc()
+			if err != nil {
+				return nil, err
+			}
+			vars[key] = value
+			continue
+		}
+		if p.eof() || p.peek() != '=' {
+			return nil, p.errorf(p.pos, "expected '=' after variable name %s", key)
+		}
+		p.pos++
+		eq := p.pos
+		p.skip(" \t")
+		if p.pos > eq && !p.eof() && p.peek() == '#' {
+			p.skipLine()
+			vars[key] = ""
+			continue
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+}
+
+func (p *dotenvParser) key() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' ||
+			p.pos > start && (c >= '0' && c <= '9' || c == '.') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return "", p.errorf(p.pos, "unexpected character %q in variable name", r)
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *dotenvParser) value() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	var value string
+	switch quote := p.peek(); quote {
+	case '\'', '`':
+		start := p.pos
+		end := strings.IndexByte(p.src[start+1:], quote)
+		if end < 0 {
+			return "", p.errorf(start, "unterminated quoted value")
+		}
+		value = p.src[start+1 : start+1+end]
+		p.pos = start + end + 2
+	case '"':
+		var err error
+		if value, err = p.doubleQuoted(); err != nil {
+			return "", err
+		}
+	default:
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		value = p.src[p.pos : p.pos+end]
+		p.pos += end
+		for i := 1; i < len(value); i++ {
+			if value[i] == '#' && isBlank(value[i-1]) {
+				value = value[:i]
+				break
+			}
+		}
+		return strings.TrimRight(value, " \t"), nil
+	}
+
+	p.skip(" \t")
+	if !p.eof() && p.peek() != '\n' {
+		if p.peek() != '#' {
+			r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+			return "", p.errorf(p.pos, "unexpected character %q after quoted value", r)
+		}
+		p.skipLine()
+	}
+	return value, nil
+}
+
+func (p *dotenvParser) doubleQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.peek(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'', '$', '`':
+				b.WriteByte(e)
+			case '\n':
+				// A backslash at the end of a line continues the value.
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf(start, "unterminated quoted value")
+}
+
+func (p *dotenvParser) heredoc() (string, error) {
+	start := p.pos
+	p.pos += 2
+	delim := strings.TrimRight(p.line(), " \t")
+	if delim == "" {
+		return "", p.errorf(start+2, "missing heredoc delimiter")
+	}
+
+	var lines []string
+	for !p.eof() {
+		p.pos++
+		line := p.line()
+		if line == delim {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+	return "", p.errorf(start, "unterminated heredoc, expected %s", delim)
+}
+
+// line returns the rest of the current line and moves to its end.
+func (p *dotenvParser) line() string {
+	start := p.pos
+	p.skipLine()
+	return p.src[start:p.pos]
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	if end := strings.IndexByte(p.src[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+// errorf returns a SyntaxError positioned at the given byte offset.
+func (p *dotenvParser) errorf(pos int, format string, args ...interface{}) error {
+	lineStart := strings.LastIndexByte(p.src[:pos], '\n') + 1
+	return &SyntaxError{
+		File:   p.name,
+		Line:   strings.Count(p.src[:pos], "\n") + 1,
+		Column: utf8.RuneCountInString(p.src[lineStart:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,167 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDotenvForTest(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	assertNoError(t, err, "WriteFile")
+	return path
+}
+
+func TestParse(t *testing.T) {
+	input := strings.Join([]string{
+		"# a comment",
+		"",
+		"PLAIN=value",
+		"export EXPORTED=exported",
+		"  SPACED = spaced value  ",
+		"INLINE=value # comment",
+		"HASH=value#not-a-comment",
+		"EMPTY=",
+		"EMPTY_COMMENT= # comment",
+		"SINGLE='single $HOME \\n'",
+		`DOUBLE="line1\nline2\t\"quoted\" \\ \$HOME"`,
+		"BACKTICK=`it's \"both\"`",
+		"QUOTED_COMMENT='value' # comment",
+		"MULTI_SINGLE='line1",
+		"line2'",
+		`MULTI_DOUBLE="line1`,
+		`line2"`,
+		"CRLF=value\r",
+		"dotted.key=dotted",
+	}, "\n")
+
+	vars, err := Parse(strings.NewReader(input))
+	assertNoError(t, err, "Parse")
+
+	expected := Map{
+		"PLAIN":          "value",
+		"EXPORTED":       "exported",
+		"SPACED":         "spaced value",
+		"INLINE":         "value",
+		"HASH":           "value#not-a-comment",
+		"EMPTY":          "",
+		"EMPTY_COMMENT":  "",
+		"SINGLE":         "single $HOME \\n",
+		"DOUBLE":         "line1\nline2\t\"quoted\" \\ $HOME",
+		"BACKTICK":       "it's \"both\"",
+		"QUOTED_COMMENT": "value",
+		"MULTI_SINGLE":   "line1\nline2",
+		"MULTI_DOUBLE":   "line1\nline2",
+		"CRLF":           "value",
+		"dotted.key":     "dotted",
+	}
+
+	assertEqual(t, expected, vars, "Parse")
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"MissingEquals", "KEY value", "1:5: expected '=' after variable name KEY"},
+		{"InvalidKey", "A=1\n 1KEY=value", "2:2: unexpected character '1' in variable name"},
+		{"UnterminatedSingle", "A=1\nKEY='value", "2:5: unterminated quoted value"},
+		{"UnterminatedDouble", "KEY=\"value\nmore", "1:5: unterminated quoted value"},
+		{"TrailingGarbage", "KEY=\"value\" garbage", "1:13: unexpected character 'g' after quoted value"},
+		{"Unicode", "KEY=\"ü\"x", "1:8: unexpected character 'x' after quoted value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			assertError(t, err, "Parse")
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected *SyntaxError, got %T", err)
+			}
+			assertEqual(t, tt.expected, err.Error(), "Parse error")
+		})
+	}
+}
+
+func TestReadFileSyntaxError(t *testing.T) {
+	path := writeDotenvForTest(t, ".env", "OK=1\nBROKEN\n")
+
+	_, err := Read(path)
+	assertError(t, err, "Read")
+	assertEqual(t, path+":2:7: expected '=' after variable name BROKEN", err.Error(), "Read error")
+}
+
+func TestRead(t *testing.T) {
+	base := writeDotenvForTest(t, ".env", "A=base\nB=base\n")
+	local := writeDotenvForTest(t, ".env.local", "B=local\n")
+
+	vars, err := Read(base, local)
+	assertNoError(t, err, "Read")
+	assertEqual(t, Map{"A": "base", "B": "local"}, vars, "Read")
+
+	_, err = Read(filepath.Join(t.TempDir(), "missing"))
+	assertError(t, err, "Read missing")
+}
+
+func TestLoad(t *testing.T) {
+	setEnvForTest(t, "TEST_LOAD_EXISTING", "existing")
+	t.Cleanup(func() {
+		_ = Unset("TEST_LOAD_NEW")
+		_ = Unset("TEST_LOAD_SECOND")
+	})
+
+	first := writeDotenvForTest(t, ".env", "TEST_LOAD_EXISTING=loaded\nTEST_LOAD_NEW=first\n")
+	second := writeDotenvForTest(t, ".env.local", "TEST_LOAD_NEW=second\nTEST_LOAD_SECOND=second\n")
+
+	err := Load(first, second)
+	assertNoError(t, err, "Load")
+
+	assertEqual(t, "existing", GetWithFallback("TEST_LOAD_EXISTING", ""), "existing is kept")
+	assertEqual(t, "first", GetWithFallback("TEST_LOAD_NEW", ""), "first file wins")
+	assertEqual(t, "second", GetWithFallback("TEST_LOAD_SECOND", ""), "second file is loaded")
+}
+
+func TestOverload(t *testing.T) {
+	setEnvForTest(t, "TEST_OVERLOAD_EXISTING", "existing")
+	t.Cleanup(func() {
+		_ = Unset("TEST_OVERLOAD_NEW")
+	})
+
+	first := writeDotenvForTest(t, ".env", "TEST_OVERLOAD_EXISTING=loaded\nTEST_OVERLOAD_NEW=first\n")
+	second := writeDotenvForTest(t, ".env.local", "TEST_OVERLOAD_NEW=second\n")
+
+	err := Overload(first, second)
+	assertNoError(t, err, "Overload")
+
+	assertEqual(t, "loaded", GetWithFallback("TEST_OVERLOAD_EXISTING", ""), "existing is overridden")
+	assertEqual(t, "second", GetWithFallback("TEST_OVERLOAD_NEW", ""), "last file wins")
+}
+
+func TestLoadDefaultFile(t *testing.T) {
+	wd, err := os.Getwd()
+	assertNoError(t, err, "Getwd")
+	assertNoError(t, os.Chdir(t.TempDir()), "Chdir")
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+		_ = Unset("TEST_LOAD_DEFAULT")
+	})
+
+	err = Load()
+	assertError(t, err, "Load without .env")
+
+	err = os.WriteFile(".env", []byte("TEST_LOAD_DEFAULT=value"), 0o600)
+	assertNoError(t, err, "WriteFile")
+
+	err = Load()
+	assertNoError(t, err, "Load")
+	assertEqual(t, "value", GetWithFallback("TEST_LOAD_DEFAULT", ""), "Load default file")
+}
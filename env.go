@@ -1,7 +1,6 @@
 package env
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -24,7 +23,7 @@ func Lookup(key string) (string, bool) {
 // Require checks if an environment variable is set and returns an error if it is not.
 func Require(key string) error {
 	if _, ok := Lookup(key); !ok {
-		return fmt.Errorf("required environment variable %s is not set", key)
+		return &RequiredError{Key: key}
 	}
 	return nil
 }
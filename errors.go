@@ -0,0 +1,205 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSet is returned when a variable is not present in the source. Errors
+// for missing required variables also match it with errors.Is.
+var ErrNotSet = errors.New("environment variable not set")
+
+// ParseError is returned when the value of a variable cannot be converted to
+// the type of the field or getter it is read into.
+type ParseError struct {
+	Key       string       // variable the value was read from
+	FieldPath string       // path of the struct field, empty for getters
+	Type      reflect.Type // type the value was converted to
+	Value     string       // value that failed to parse
+	Err       error        // underlying conversion error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid value for environment variable %s: %v", e.Key, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RangeError is returned, wrapped in a ParseError, when a value can't be
+// represented by the numeric type of a field, either because it is out of
+// range or, for float32 fields, because it would lose precision. Range errors
+// that aren't lossy match strconv.ErrRange.
+type RangeError struct {
+	Type  reflect.Type // numeric type of the field
+	Value string       // value that could not be represented
+	Lossy bool         // whether the value is in range, but would lose precision
+}
+
+func (e *RangeError) Error() string {
+	if e.Lossy {
+		return fmt.Sprintf("value %s would lose precision as %s", e.Value, e.Type)
+	}
+	return fmt.Sprintf("value %s out of range for %s", e.Value, e.Type)
+}
+
+// Is reports whether target is strconv.ErrRange.
+func (e *RangeError) Is(target error) bool {
+	return !e.Lossy && target == strconv.ErrRange
+}
+
+// RequiredError is returned when a required variable is not set.
+type RequiredError struct {
+	Key       string // primary variable of the field
+	FieldPath string // path of the struct field, empty for Require
+}
+
+func (e *RequiredError) Error() string {
+	return fmt.Sprintf("required environment variable %s is not set", e.Key)
+}
+
+// Is reports whether target is ErrNotSet.
+func (e *RequiredError) Is(target error) bool {
+	return target == ErrNotSet
+}
+
+// IndexError is returned when the indexes of the variables of a slice of
+// structs aren't contiguous from 0.
+type IndexError struct {
+	Key       string // prefix of the missing element's variables, e.g. UPSTREAM_1
+	FieldPath string // path of the struct field
+	Found     string // prefix of the element found in its place, e.g. UPSTREAM_2
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("missing %s: indexed variables must be contiguous from 0, found %s", e.Key, e.Found)
+}
+
+// FileError is returned when the file named by the variable of a `file`
+// field can't be read.
+type FileError struct {
+	Key       string // variable the path was read from
+	FieldPath string // path of the struct field
+	Path      string // path of the file
+	Err       error  // underlying error, such as an *fs.PathError
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("cannot read file for environment variable %s: %v", e.Key, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalError is returned when the value of a field cannot be formatted as
+// a variable that unmarshals back to the same value.
+type MarshalError struct {
+	Key       string       // variable the value would be written to
+	FieldPath string       // path of the struct field
+	Type      reflect.Type // type of the field
+	Err       error        // underlying formatting error
+}
+
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("cannot marshal %s into environment variable %s: %v", e.FieldPath, e.Key, e.Err)
+}
+
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// TagError is returned when the tag of a struct field is invalid, or the
+// field has a type that can't be unmarshalled.
+type TagError struct {
+	FieldPath string // path of the struct field
+	Msg       string // description of the problem
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("invalid field %s: %s", e.FieldPath, e.Msg)
+}
+
+// ExpansionError is returned, wrapped in a ParseError, when expanding
+// ${VAR:?message} with VAR unset or empty, or ${VAR?message} with VAR unset.
+type ExpansionError struct {
+	Name  string // variable that was referenced
+	Msg   string // message of the reference, if any
+	Empty bool   // whether an empty value is also an error, as with :?
+
+	expanded bool // whether Msg was expanded from references, which may hold values
+}
+
+func (e *ExpansionError) Error() string {
+	switch {
+	case e.Msg != "":
+		return fmt.Sprintf("%s: %s", e.Name, e.Msg)
+	case e.Empty:
+		return fmt.Sprintf("%s: parameter null or not set", e.Name)
+	default:
+		return fmt.Sprintf("%s: parameter not set", e.Name)
+	}
+}
+
+// CycleError is returned, wrapped in a ParseError, when expanding a field
+// refers back to itself through the fields it references.
+type CycleError struct {
+	Chain []string // variables in the order they were referenced
+}
+
+func (e *CycleError) Error() string {
+	return "expansion cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// ReferenceError is returned, wrapped in a ParseError, when a field with
+// `expand` references a secret field without being secret itself, or a
+// variable outside the allowlist set by WithExpandAllowlist.
+type ReferenceError struct {
+	Name   string // name in the reference
+	Key    string // variable the reference resolves to
+	Secret bool   // whether the variable is a secret field
+}
+
+func (e *ReferenceError) Error() string {
+	if e.Secret {
+		return fmt.Sprintf("reference ${%s} to secret %s from a field that is not secret", e.Name, e.Key)
+	}
+	return fmt.Sprintf("reference ${%s} is not allowed", e.Name)
+}
+
+// UnusedVariableError is returned in strict mode for a variable that shares
+// the prefix of a struct, but isn't read by any of its fields.
+type UnusedVariableError struct {
+	Key        string // variable that was not used
+	Suggestion string // closest variable read by a field, if any
+}
+
+func (e *UnusedVariableError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unused environment variable %s, did you mean %s?", e.Key, e.Suggestion)
+	}
+	return fmt.Sprintf("unused environment variable %s", e.Key)
+}
+
+// InvalidUnmarshalError describes an invalid argument passed to Unmarshal,
+// which must be a non-nil pointer to a struct.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "cannot unmarshal into nil"
+	}
+	if e.Type.Kind() != reflect.Pointer {
+		return "cannot unmarshal into non-pointer " + e.Type.String()
+	}
+	if e.Type.Elem().Kind() != reflect.Struct {
+		return "cannot unmarshal into non-struct " + e.Type.String()
+	}
+	return "cannot unmarshal into nil " + e.Type.String()
+}
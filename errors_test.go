@@ -0,0 +1,126 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestErrNotSet(t *testing.T) {
+	_, err := GetFrom(Map{}, "MISSING")
+	if !errors.Is(err, ErrNotSet) {
+		t.Errorf("expected ErrNotSet, got %v", err)
+	}
+	assertEqual(t, "environment variable not set: MISSING", err.Error(), "GetFrom error")
+
+	_, err = GetIntFrom(Map{}, "MISSING")
+	if !errors.Is(err, ErrNotSet) {
+		t.Errorf("expected ErrNotSet, got %v", err)
+	}
+
+	err = Require("TEST_ERR_NOT_SET")
+	if !errors.Is(err, ErrNotSet) {
+		t.Errorf("expected ErrNotSet, got %v", err)
+	}
+}
+
+func TestGetterParseError(t *testing.T) {
+	_, err := GetIntFrom(Map{"PORT": "eighty"}, "PORT")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	assertEqual(t, "PORT", parseErr.Key, "Key")
+	assertEqual(t, "", parseErr.FieldPath, "FieldPath")
+	assertEqual(t, reflect.TypeOf(0), parseErr.Type, "Type")
+	assertEqual(t, "eighty", parseErr.Value, "Value")
+
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected error to wrap strconv.ErrSyntax, got %v", err)
+	}
+
+	_, err = GetFloatSliceFrom(Map{"RATIOS": "1.5,half"}, "RATIOS")
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	assertEqual(t, reflect.TypeOf([]float64(nil)), parseErr.Type, "Type")
+}
+
+func TestUnmarshalParseError(t *testing.T) {
+	type DatabaseConfig struct {
+		Port int `env:"PORT|DB_PORT"`
+	}
+
+	var cfg struct {
+		Database DatabaseConfig `env:"DATABASE"`
+	}
+
+	err := UnmarshalFrom(Map{"DATABASE_DB_PORT": "eighty"}, &cfg)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	assertEqual(t, "DATABASE_DB_PORT", parseErr.Key, "Key")
+	assertEqual(t, "Database.Port", parseErr.FieldPath, "FieldPath")
+	assertEqual(t, reflect.TypeOf(0), parseErr.Type, "Type")
+	assertEqual(t, "eighty", parseErr.Value, "Value")
+	assertEqual(t, `invalid value for environment variable DATABASE_DB_PORT: strconv.ParseInt: parsing "eighty": invalid syntax`, parseErr.Error(), "Error")
+}
+
+func TestUnmarshalRequiredError(t *testing.T) {
+	type DatabaseConfig struct {
+		Password string `env:"PASSWORD,required"`
+	}
+
+	var cfg struct {
+		Database DatabaseConfig `env:"DATABASE"`
+	}
+
+	err := UnmarshalFrom(Map{}, &cfg)
+
+	var requiredErr *RequiredError
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("expected *RequiredError, got %T", err)
+	}
+	assertEqual(t, "DATABASE_PASSWORD", requiredErr.Key, "Key")
+	assertEqual(t, "Database.Password", requiredErr.FieldPath, "FieldPath")
+
+	if !errors.Is(err, ErrNotSet) {
+		t.Errorf("expected ErrNotSet, got %v", err)
+	}
+}
+
+func TestInvalidUnmarshalError(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+	}
+
+	var nilConfig *Config
+	var notStruct int
+
+	tests := []struct {
+		name     string
+		data     interface{}
+		expected string
+	}{
+		{"Nil", nil, "cannot unmarshal into nil"},
+		{"NonPointer", Config{}, "cannot unmarshal into non-pointer env.Config"},
+		{"NilPointer", nilConfig, "cannot unmarshal into nil *env.Config"},
+		{"NonStruct", &notStruct, "cannot unmarshal into non-struct *int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal(tt.data)
+
+			var invalidErr *InvalidUnmarshalError
+			if !errors.As(err, &invalidErr) {
+				t.Fatalf("expected *InvalidUnmarshalError, got %T", err)
+			}
+			assertEqual(t, tt.expected, err.Error(), "Error")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// secretPlaceholder is written in place of the value of secret fields.
+const secretPlaceholder = "<secret>"
+
+// GenerateExample writes an example dotenv file for a struct to w, such as a
+// .env.example to commit alongside the code. Each variable is written with
+// its full prefix and its default value, preceded by a comment with its
+// description from the `desc` tag option, and whether it is required. Values
+// are only ever taken from tags, and secret fields are written with a
+// placeholder instead of their default.
+//
+// Fields of slices of structs are written for index 0, and fields of maps of
+// structs are commented out, with <NAME> in place of the map key.
+func GenerateExample(data interface{}, w io.Writer) error {
+	t, err := structTypeOf(data)
+	if err != nil {
+		return fmt.Errorf("cannot generate example: %w", err)
+	}
+
+	b := bufio.NewWriter(w)
+	for i, field := range newDecoder(nil).fields(t, "", "") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if comment := exampleComment(field); comment != "" {
+			fmt.Fprintf(b, "# %s\n", comment)
+		}
+
+		value := field.opts.fallback
+		if field.opts.secret {
+			value = secretPlaceholder
+		}
+		key := strings.ReplaceAll(field.key, indexPlaceholder, "0")
+		if strings.Contains(key, namePlaceholder) {
+			b.WriteString("# ")
+		}
+		fmt.Fprintf(b, "%s=%s\n", key, quoteDotenv(value))
+	}
+	return b.Flush()
+}
+
+// exampleComment returns the description of a field followed by its
+// notable options, such as "Database password (required, secret)".
+func exampleComment(field fieldInfo) string {
+	var notes []string
+	if field.opts.required {
+		notes = append(notes, "required")
+	}
+	if field.opts.secret {
+		notes = append(notes, "secret")
+	}
+	if field.opts.file {
+		notes = append(notes, "path to a file containing the value")
+	}
+	if len(field.opts.enum) > 0 {
+		notes = append(notes, "one of "+strings.Join(field.opts.enum, ", "))
+	}
+	if len(field.aliases) > 0 {
+		notes = append(notes, "or "+strings.Join(field.aliases, ", "))
+	}
+
+	comment := field.opts.desc
+	if len(notes) > 0 {
+		if comment != "" {
+			comment += " "
+		}
+		comment += "(" + strings.Join(notes, ", ") + ")"
+	}
+	return comment
+}
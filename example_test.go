@@ -0,0 +1,75 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	type Database struct {
+		Host     string `env:"HOST,required,desc=Database host"`
+		Port     int    `env:"PORT,default=5432"`
+		Password string `env:"PASSWORD,secret,default=hunter2,desc=[Password, never committed]"`
+	}
+
+	type Upstream struct {
+		URL string `env:"URL"`
+	}
+
+	type Config struct {
+		Name      string              `env:"NAME|APP_NAME,default=my app"`
+		Hosts     []string            `env:"HOSTS,default=[a,b]"`
+		Database  Database            `env:"DB"`
+		TLSCert   string              `env:"TLS_CERT,file"`
+		Upstreams []Upstream          `env:"UPSTREAM"`
+		Replicas  map[string]Database `env:"REPLICA"`
+		Ignored   string
+	}
+
+	cfg := Config{Database: Database{Password: "real-password"}}
+
+	var b strings.Builder
+	err := GenerateExample(&cfg, &b)
+	assertNoError(t, err, "GenerateExample")
+
+	want := strings.Join([]string{
+		"# (or APP_NAME)",
+		`NAME="my app"`,
+		"",
+		"HOSTS=a,b",
+		"",
+		"# Database host (required)",
+		"DB_HOST=",
+		"",
+		"DB_PORT=5432",
+		"",
+		"# Password, never committed (secret)",
+		`DB_PASSWORD="<secret>"`,
+		"",
+		"# (path to a file containing the value)",
+		"TLS_CERT=",
+		"",
+		"UPSTREAM_0_URL=",
+		"",
+		"# Database host (required)",
+		"# REPLICA_<NAME>_HOST=",
+		"",
+		"# REPLICA_<NAME>_PORT=5432",
+		"",
+		"# Password, never committed (secret)",
+		`# REPLICA_<NAME>_PASSWORD="<secret>"`,
+		"",
+	}, "\n")
+	assertEqual(t, want, b.String(), "example")
+
+	vars, err := Parse(strings.NewReader(b.String()))
+	assertNoError(t, err, "Parse")
+	assertEqual(t, "<secret>", vars["DB_PASSWORD"], "DB_PASSWORD")
+	assertEqual(t, "a,b", vars["HOSTS"], "HOSTS")
+}
+
+func TestGenerateExampleInvalid(t *testing.T) {
+	err := GenerateExample("config", &strings.Builder{})
+	assertError(t, err, "GenerateExample")
+	assertEqual(t, "cannot generate example: expected a struct or a pointer to one, got string", err.Error(), "error")
+}
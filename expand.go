@@ -0,0 +1,201 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// expander expands variable references in a value using the shell's
+// parameter expansion syntax:
+//
+//	$VAR, ${VAR}          value of VAR
+//	${VAR:-word}          word if VAR is unset or empty
+//	${VAR-word}           word if VAR is unset
+//	${VAR:=word}          like :-, and VAR is word for the rest of the value
+//	${VAR=word}           like -, and VAR is word for the rest of the value
+//	${VAR:?message}       an *ExpansionError if VAR is unset or empty
+//	${VAR?message}        an *ExpansionError if VAR is unset
+//	${VAR:+word}          word if VAR is set and not empty
+//	${VAR+word}           word if VAR is set
+//	${VAR:offset}         VAR from offset, counted from the end if negative
+//	${VAR:offset:length}  length bytes of VAR from offset
+//	${#VAR}               length of VAR
+//	$$                    a literal $
+//
+// Words are expanded only when used, and may contain references themselves.
+type expander struct {
+	lookup   func(name string) (string, bool, error)
+	assigned map[string]string // values assigned by := and =
+}
+
+func (e *expander) get(name string) (string, bool, error) {
+	if value, ok := e.assigned[name]; ok {
+		return value, true, nil
+	}
+	return e.lookup(name)
+}
+
+func (e *expander) expand(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch c := s[i+1]; {
+		case c == '$':
+			b.WriteByte('$')
+			i++
+		case c == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated reference %s", s[i:])
+			}
+			value, err := e.expandBraced(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		case isLetter(c) || c == '_':
+			end := i + 2
+			for end < len(s) && (isAlphaNum(s[end]) || s[end] == '_') {
+				end++
+			}
+			value, _, err := e.get(s[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the brace closing a reference whose body
+// starts at i, skipping nested references, or -1 if there is none.
+func closingBrace(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// expandBraced expands the body of a ${...} reference.
+func (e *expander) expandBraced(body string) (string, error) {
+	if name, ok := strings.CutPrefix(body, "#"); ok && name != "" {
+		if !isShellKey(name) {
+			return "", fmt.Errorf("bad substitution ${%s}", body)
+		}
+		value, _, err := e.get(name)
+		return strconv.Itoa(len(value)), err
+	}
+
+	end := strings.IndexAny(body, ":-=?+")
+	if end < 0 {
+		end = len(body)
+	}
+	name, op := body[:end], body[end:]
+	if !isShellKey(name) {
+		return "", fmt.Errorf("bad substitution ${%s}", body)
+	}
+	value, set, err := e.get(name)
+	if err != nil || op == "" {
+		return value, err
+	}
+
+	// A colon not followed by an operator starts a substring.
+	colon := op[0] == ':'
+	if colon && (len(op) == 1 || !strings.ContainsRune("-=?+", rune(op[1]))) {
+		return substring(value, op[1:], body)
+	}
+	if colon {
+		op = op[1:]
+		set = set && value != ""
+	}
+	word := op[1:]
+
+	switch op[0] {
+	case '-':
+		if set {
+			return value, nil
+		}
+		return e.expand(word)
+	case '=':
+		if set {
+			return value, nil
+		}
+		value, err = e.expand(word)
+		if err != nil {
+			return "", err
+		}
+		if e.assigned == nil {
+			e.assigned = make(map[string]string)
+		}
+		e.assigned[name] = value
+		return value, nil
+	case '?':
+		if set {
+			return value, nil
+		}
+		msg, err := e.expand(word)
+		if err != nil {
+			return "", err
+		}
+		return "", &ExpansionError{Name: name, Msg: msg, Empty: colon, expanded: msg != word}
+	default: // '+'
+		if !set {
+			return "", nil
+		}
+		return e.expand(word)
+	}
+}
+
+// substring returns the part of value selected by "offset" or
+// "offset:length", where a negative offset counts from the end and a negative
+// length leaves that many bytes off the end, as in bash.
+func substring(value, spec, body string) (string, error) {
+	offsetSpec, lengthSpec, hasLength := strings.Cut(spec, ":")
+	offset, err := strconv.Atoi(strings.TrimSpace(offsetSpec))
+	if err != nil {
+		return "", fmt.Errorf("bad substitution ${%s}: invalid offset", body)
+	}
+	if offset < 0 {
+		offset = max(len(value)+offset, 0)
+	}
+	offset = min(offset, len(value))
+
+	end := len(value)
+	if hasLength {
+		length, err := strconv.Atoi(strings.TrimSpace(lengthSpec))
+		if err != nil {
+			return "", fmt.Errorf("bad substitution ${%s}: invalid length", body)
+		}
+		if length < 0 {
+			end = len(value) + length
+		} else {
+			end = min(offset+length, len(value))
+		}
+		if end < offset {
+			return "", fmt.Errorf("bad substitution ${%s}: substring expression < 0", body)
+		}
+	}
+	return value[offset:end], nil
+}
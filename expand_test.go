@@ -0,0 +1,138 @@
+package env
+
+import (
+	"errors"
+	"testing"
+)
+
+// expandMap expands value with variables from src, falling back to defaults.
+func expandMap(src Map, value string, defaults map[string]string) (string, error) {
+	e := &expander{lookup: func(name string) (string, bool, error) {
+		if value, ok := src.Lookup(name); ok {
+			return value, true, nil
+		}
+		value, ok := defaults[name]
+		return value, ok, nil
+	}}
+	return e.expand(value)
+}
+
+func TestExpandOperators(t *testing.T) {
+	src := Map{
+		"HOST":  "localhost",
+		"PORT":  "8080",
+		"EMPTY": "",
+		"PATH_": "/usr/local/bin",
+	}
+	defaults := map[string]string{"SCHEME": "https"}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"$HOST:$PORT", "localhost:8080"},
+		{"${HOST}:${PORT}", "localhost:8080"},
+		{"${SCHEME}://$HOST", "https://localhost"},
+		{"$UNSET-$HOST", "-localhost"},
+		{"${UNSET:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${HOST:-fallback}", "localhost"},
+		{"${UNSET-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${UNSET:-${HOST}:${PORT:-80}}", "localhost:8080"},
+		{"${UNSET:=assigned}/${UNSET}", "assigned/assigned"},
+		{"${EMPTY:=assigned}/$EMPTY", "assigned/assigned"},
+		{"${EMPTY=assigned}/$EMPTY", "/"},
+		{"${HOST:+set}", "set"},
+		{"${EMPTY:+set}", ""},
+		{"${EMPTY+set}", "set"},
+		{"${UNSET+set}", ""},
+		{"${HOST:?must be set}", "localhost"},
+		{"${PATH_:5}", "local/bin"},
+		{"${PATH_:5:5}", "local"},
+		{"${PATH_: -3}", "bin"},
+		{"${PATH_:1:-4}", "usr/local"},
+		{"${PATH_:100}", ""},
+		{"${#HOST}", "9"},
+		{"${#UNSET}", "0"},
+		{"$$HOST costs $$5", "$HOST costs $5"},
+		{"${UNSET:-$$}", "$"},
+		{"price: $5, $", "price: $5, $"},
+		{"${UNSET:-{a\\}}", "{a\\}"},
+	}
+
+	for _, tt := range tests {
+		got, err := expandMap(src, tt.value, defaults)
+		assertNoError(t, err, tt.value)
+		assertEqual(t, tt.want, got, tt.value)
+	}
+}
+
+func TestExpandOperatorErrors(t *testing.T) {
+	src := Map{"EMPTY": "", "HOST": "localhost"}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"${UNSET:?must be set}", "UNSET: must be set"},
+		{"${EMPTY:?}", "EMPTY: parameter null or not set"},
+		{"${UNSET?}", "UNSET: parameter not set"},
+		{"${UNSET:?${HOST} needs a port}", "UNSET: localhost needs a port"},
+		{"${HOST", "unterminated reference ${HOST"},
+		{"${}", "bad substitution ${}"},
+		{"${my.var}", "bad substitution ${my.var}"},
+		{"${#1}", "bad substitution ${#1}"},
+		{"${HOST:x}", "bad substitution ${HOST:x}: invalid offset"},
+		{"${HOST:1:x}", "bad substitution ${HOST:1:x}: invalid length"},
+		{"${HOST:5:-6}", "bad substitution ${HOST:5:-6}: substring expression < 0"},
+	}
+
+	for _, tt := range tests {
+		_, err := expandMap(src, tt.value, nil)
+		assertError(t, err, tt.value)
+		assertEqual(t, tt.want, err.Error(), tt.value)
+	}
+
+	// An unset variable in a word that isn't used is not an error.
+	got, err := expandMap(src, "${HOST:-${UNSET:?unused}}", nil)
+	assertNoError(t, err, "unused word")
+	assertEqual(t, "localhost", got, "unused word")
+}
+
+func TestUnmarshalExpansionError(t *testing.T) {
+	type Config struct {
+		URL string `env:"URL,expand,default=http://${HOST:?is required for URL}"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{}, &cfg)
+	assertEqual(t, "invalid value for environment variable URL: HOST: is required for URL", err.Error(), "error")
+
+	var expErr *ExpansionError
+	if !errors.As(err, &expErr) {
+		t.Fatalf("expected *ExpansionError, got %v", err)
+	}
+	assertEqual(t, "HOST", expErr.Name, "Name")
+
+	err = UnmarshalFrom(Map{"HOST": "example.com"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, "http://example.com", cfg.URL, "URL")
+}
+
+func TestMarshalEscapesExpand(t *testing.T) {
+	type Config struct {
+		Price string `env:"PRICE,expand"`
+		Raw   string `env:"RAW"`
+	}
+
+	in := Config{Price: "$5 ${HOST}", Raw: "$5"}
+	vars, err := Marshal(in)
+	assertNoError(t, err, "Marshal")
+	assertEqual(t, "$$5 $${HOST}", vars["PRICE"], "PRICE")
+	assertEqual(t, "$5", vars["RAW"], "RAW")
+
+	var out Config
+	assertNoError(t, UnmarshalFrom(vars, &out), "UnmarshalFrom")
+	assertEqual(t, in, out, "round trip")
+}
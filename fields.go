@@ -0,0 +1,82 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Placeholders for the index of a slice of structs and the name of a map of
+// structs in the keys of their fields.
+const (
+	indexPlaceholder = "<N>"
+	namePlaceholder  = "<NAME>"
+)
+
+// fieldInfo describes the variable a struct field is read from, for
+// documenting the variables of a struct without reading them.
+type fieldInfo struct {
+	path    string       // path of the struct field
+	key     string       // primary variable, including any prefix
+	aliases []string     // alternative variables, including any prefix
+	typ     reflect.Type // type of the field
+	opts    tagOptions   // options of the field's tag
+}
+
+// structTypeOf returns the struct type of data, which must be a struct or a
+// pointer to one.
+func structTypeOf(data interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(data)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct or a pointer to one, got %v", reflect.TypeOf(data))
+	}
+	return t, nil
+}
+
+// fields returns the variables read by the fields of a struct type in field
+// order, following the same prefixes as Unmarshal. The fields of slices and
+// maps of structs are described once, with a placeholder for the index or
+// name in their keys.
+func (d *decoder) fields(t reflect.Type, prefix, path string) []fieldInfo {
+	var fields []fieldInfo
+	for _, fp := range d.plan(t).fields {
+		fieldType := t.Field(fp.index).Type
+		fieldPath := path + fp.name
+
+		switch d.kind(fp, fieldType) {
+		case fieldNested:
+			nestedPrefix := prefix
+			if fp.tag != "" {
+				nestedPrefix = prefix + fp.tag + "_"
+			}
+			fields = append(fields, d.fields(fieldType, nestedPrefix, fieldPath+".")...)
+		case fieldStructSlice:
+			elemPrefix := prefix + fp.opts.keys[0] + "_" + indexPlaceholder + "_"
+			elemPath := fieldPath + "[" + indexPlaceholder + "]."
+			fields = append(fields, d.fields(structElemType(fieldType), elemPrefix, elemPath)...)
+		case fieldStructMap:
+			elemPrefix := prefix + fp.opts.keys[0] + "_" + namePlaceholder + "_"
+			elemPath := fieldPath + "[" + namePlaceholder + "]."
+			fields = append(fields, d.fields(structElemType(fieldType), elemPrefix, elemPath)...)
+		case fieldValue:
+			info := fieldInfo{path: fieldPath, key: prefix + fp.opts.keys[0], typ: withoutSecrets(fieldType), opts: fp.opts}
+			for _, alias := range fp.opts.keys[1:] {
+				info.aliases = append(info.aliases, prefix+alias)
+			}
+			fields = append(fields, info)
+		}
+	}
+	return fields
+}
+
+// structElemType returns the struct type of the elements of a slice or map
+// of structs, or of pointers to them.
+func structElemType(t reflect.Type) reflect.Type {
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem
+}
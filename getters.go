@@ -2,16 +2,23 @@ package env
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Get returns the value of an environment variable.
 func Get(key string) (string, error) {
-	if value, ok := Lookup(key); ok {
+	return GetFrom(OS, key)
+}
+
+// GetFrom returns the value of a variable from the given source.
+func GetFrom(src Source, key string) (string, error) {
+	if value, ok := src.Lookup(key); ok {
 		return value, nil
 	}
-	return "", fmt.Errorf("environment variable %s not set", key)
+	return "", fmt.Errorf("%w: %s", ErrNotSet, key)
 }
 
 // GetWithFallback returns the value of an environment variable or a fallback
@@ -25,11 +32,20 @@ func GetWithFallback(key string, fallback string) string {
 
 // GetBool returns the value of an environment variable as a boolean.
 func GetBool(key string) (bool, error) {
-	value, err := Get(key)
+	return GetBoolFrom(OS, key)
+}
+
+// GetBoolFrom returns the value of a variable as a boolean from the given source.
+func GetBoolFrom(src Source, key string) (bool, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return false, err
 	}
-	return parseBool(value)
+	result, err := parseBool(value)
+	if err != nil {
+		return false, &ParseError{Key: key, Type: reflect.TypeOf(false), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetBoolWithFallback returns the value of an environment variable as a boolean
@@ -43,11 +59,20 @@ func GetBoolWithFallback(key string, fallback bool) (bool, error) {
 
 // GetInt returns the value of an environment variable as an integer.
 func GetInt(key string) (int, error) {
-	value, err := Get(key)
+	return GetIntFrom(OS, key)
+}
+
+// GetIntFrom returns the value of a variable as an integer from the given source.
+func GetIntFrom(src Source, key string) (int, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return 0, err
 	}
-	return strconv.Atoi(value)
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParseError{Key: key, Type: reflect.TypeOf(0), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetIntWithFallback returns the value of an environment variable as an integer
@@ -61,11 +86,20 @@ func GetIntWithFallback(key string, fallback int) (int, error) {
 
 // GetFloat returns the value of an environment variable as a float.
 func GetFloat(key string) (float64, error) {
-	value, err := Get(key)
+	return GetFloatFrom(OS, key)
+}
+
+// GetFloatFrom returns the value of a variable as a float from the given source.
+func GetFloatFrom(src Source, key string) (float64, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return 0, err
 	}
-	return strconv.ParseFloat(value, 64)
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ParseError{Key: key, Type: reflect.TypeOf(0.0), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetFloatWithFallback returns the value of an environment variable as a float
@@ -77,11 +111,74 @@ func GetFloatWithFallback(key string, fallback float64) (float64, error) {
 	return fallback, nil
 }
 
+// GetDuration returns the value of an environment variable as a duration. In
+// addition to the units accepted by time.ParseDuration, "d" (days) and "w"
+// (weeks) are supported.
+func GetDuration(key string) (time.Duration, error) {
+	return GetDurationFrom(OS, key)
+}
+
+// GetDurationFrom returns the value of a variable as a duration from the given source.
+func GetDurationFrom(src Source, key string) (time.Duration, error) {
+	value, err := GetFrom(src, key)
+	if err != nil {
+		return 0, err
+	}
+	result, err := parseDuration(value)
+	if err != nil {
+		return 0, &ParseError{Key: key, Type: durationType, Value: value, Err: err}
+	}
+	return result, nil
+}
+
+// GetDurationWithFallback returns the value of an environment variable as a duration
+// or a fallback value if the environment variable is not set or invalid.
+func GetDurationWithFallback(key string, fallback time.Duration) (time.Duration, error) {
+	if value, err := GetDuration(key); err == nil {
+		return value, nil
+	}
+	return fallback, nil
+}
+
+// GetTime returns the value of an environment variable as a time parsed with the
+// given layout, or RFC 3339 if the layout is empty.
+func GetTime(key, layout string) (time.Time, error) {
+	return GetTimeFrom(OS, key, layout)
+}
+
+// GetTimeFrom returns the value of a variable as a time from the given source.
+func GetTimeFrom(src Source, key, layout string) (time.Time, error) {
+	value, err := GetFrom(src, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	result, err := parseTime(value, layout)
+	if err != nil {
+		return time.Time{}, &ParseError{Key: key, Type: timeType, Value: value, Err: err}
+	}
+	return result, nil
+}
+
+// GetTimeWithFallback returns the value of an environment variable as a time
+// or a fallback value if the environment variable is not set or invalid.
+func GetTimeWithFallback(key, layout string, fallback time.Time) (time.Time, error) {
+	if value, err := GetTime(key, layout); err == nil {
+		return value, nil
+	}
+	return fallback, nil
+}
+
 // -- Slice Getters --
 
 // GetStringSlice returns the value of a comma-separated environment variable as a slice of strings.
 func GetStringSlice(key string) ([]string, error) {
-	value, err := Get(key)
+	return GetStringSliceFrom(OS, key)
+}
+
+// GetStringSliceFrom returns the value of a comma-separated variable from the given source
+// as a slice of strings.
+func GetStringSliceFrom(src Source, key string) ([]string, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return nil, err
 	}
@@ -99,11 +196,21 @@ func GetStringSliceWithFallback(key string, fallback []string) ([]string, error)
 
 // GetBoolSlice returns the value of a comma-separated environment variable as a slice of bools.
 func GetBoolSlice(key string) ([]bool, error) {
-	value, err := Get(key)
+	return GetBoolSliceFrom(OS, key)
+}
+
+// GetBoolSliceFrom returns the value of a comma-separated variable from the given source
+// as a slice of bools.
+func GetBoolSliceFrom(src Source, key string) ([]bool, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return nil, err
 	}
-	return parseBoolSlice(value)
+	result, err := parseBoolSlice(value)
+	if err != nil {
+		return nil, &ParseError{Key: key, Type: reflect.TypeOf([]bool(nil)), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetBoolSliceWithFallback returns the value of a comma-separated environment variable as a slice
@@ -117,11 +224,21 @@ func GetBoolSliceWithFallback(key string, fallback []bool) ([]bool, error) {
 
 // GetIntSlice returns the value of a comma-separated environment variable as a slice of ints.
 func GetIntSlice(key string) ([]int, error) {
-	value, err := Get(key)
+	return GetIntSliceFrom(OS, key)
+}
+
+// GetIntSliceFrom returns the value of a comma-separated variable from the given source
+// as a slice of ints.
+func GetIntSliceFrom(src Source, key string) ([]int, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return nil, err
 	}
-	return parseIntSlice(value)
+	result, err := parseIntSlice(value)
+	if err != nil {
+		return nil, &ParseError{Key: key, Type: reflect.TypeOf([]int(nil)), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetIntSliceWithFallback returns the value of a comma-separated environment variable as a slice
@@ -135,11 +252,21 @@ func GetIntSliceWithFallback(key string, fallback []int) ([]int, error) {
 
 // GetUintSlice returns the value of a comma-separated environment variable as a slice of uints.
 func GetUintSlice(key string) ([]uint, error) {
-	value, err := Get(key)
+	return GetUintSliceFrom(OS, key)
+}
+
+// GetUintSliceFrom returns the value of a comma-separated variable from the given source
+// as a slice of uints.
+func GetUintSliceFrom(src Source, key string) ([]uint, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return nil, err
 	}
-	return parseUintSlice(value)
+	result, err := parseUintSlice(value)
+	if err != nil {
+		return nil, &ParseError{Key: key, Type: reflect.TypeOf([]uint(nil)), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetUintSliceWithFallback returns the value of a comma-separated environment variable as a slice
@@ -153,11 +280,21 @@ func GetUintSliceWithFallback(key string, fallback []uint) ([]uint, error) {
 
 // GetFloatSlice returns the value of a comma-separated environment variable as a slice of floats.
 func GetFloatSlice(key string) ([]float64, error) {
-	value, err := Get(key)
+	return GetFloatSliceFrom(OS, key)
+}
+
+// GetFloatSliceFrom returns the value of a comma-separated variable from the given source
+// as a slice of floats.
+func GetFloatSliceFrom(src Source, key string) ([]float64, error) {
+	value, err := GetFrom(src, key)
 	if err != nil {
 		return nil, err
 	}
-	return parseFloatSlice(value)
+	result, err := parseFloatSlice(value)
+	if err != nil {
+		return nil, &ParseError{Key: key, Type: reflect.TypeOf([]float64(nil)), Value: value, Err: err}
+	}
+	return result, nil
 }
 
 // GetFloatSliceWithFallback returns the value of a comma-separated environment variable as a slice
@@ -2,6 +2,7 @@ package env
 
 import (
 	"testing"
+	"time"
 )
 
 func TestGet(t *testing.T) {
@@ -223,3 +224,84 @@ func TestGetFloatSliceWithFallback(t *testing.T) {
 	assertNoError(t, err, "GetFloatSliceWithFallback TEST_FLOAT_SLICE_WITH_FALLBACK")
 	assertEqual(t, []float64{4.4, 5.5, 6.6}, value, "GetFloatSliceWithFallback TEST_FLOAT_SLICE_WITH_FALLBACK")
 }
+
+func TestGetFrom(t *testing.T) {
+	src := Map{
+		"STRING": "value",
+		"BOOL":   "true",
+		"INT":    "42",
+		"FLOAT":  "4.2",
+		"SLICE":  "1,2",
+	}
+
+	s, err := GetFrom(src, "STRING")
+	assertNoError(t, err, "GetFrom STRING")
+	assertEqual(t, "value", s, "GetFrom STRING")
+
+	_, err = GetFrom(src, "MISSING")
+	assertError(t, err, "GetFrom MISSING")
+
+	b, err := GetBoolFrom(src, "BOOL")
+	assertNoError(t, err, "GetBoolFrom BOOL")
+	assertEqual(t, true, b, "GetBoolFrom BOOL")
+
+	i, err := GetIntFrom(src, "INT")
+	assertNoError(t, err, "GetIntFrom INT")
+	assertEqual(t, 42, i, "GetIntFrom INT")
+
+	f, err := GetFloatFrom(src, "FLOAT")
+	assertNoError(t, err, "GetFloatFrom FLOAT")
+	assertEqual(t, 4.2, f, "GetFloatFrom FLOAT")
+
+	ss, err := GetStringSliceFrom(src, "SLICE")
+	assertNoError(t, err, "GetStringSliceFrom SLICE")
+	assertEqual(t, []string{"1", "2"}, ss, "GetStringSliceFrom SLICE")
+
+	is, err := GetIntSliceFrom(src, "SLICE")
+	assertNoError(t, err, "GetIntSliceFrom SLICE")
+	assertEqual(t, []int{1, 2}, is, "GetIntSliceFrom SLICE")
+
+	us, err := GetUintSliceFrom(src, "SLICE")
+	assertNoError(t, err, "GetUintSliceFrom SLICE")
+	assertEqual(t, []uint{1, 2}, us, "GetUintSliceFrom SLICE")
+
+	fs, err := GetFloatSliceFrom(src, "SLICE")
+	assertNoError(t, err, "GetFloatSliceFrom SLICE")
+	assertEqual(t, []float64{1, 2}, fs, "GetFloatSliceFrom SLICE")
+
+	_, err = GetBoolSliceFrom(src, "SLICE")
+	assertError(t, err, "GetBoolSliceFrom SLICE")
+}
+
+func TestGetDuration(t *testing.T) {
+	setEnvForTest(t, "TEST_DURATION", "1d12h")
+
+	value, err := GetDuration("TEST_DURATION")
+	assertNoError(t, err, "GetDuration TEST_DURATION")
+	assertEqual(t, 36*time.Hour, value, "GetDuration TEST_DURATION")
+
+	setEnvForTest(t, "TEST_DURATION", "30")
+
+	_, err = GetDuration("TEST_DURATION")
+	assertError(t, err, "GetDuration TEST_DURATION invalid")
+
+	value, err = GetDurationWithFallback("TEST_DURATION", time.Minute)
+	assertNoError(t, err, "GetDurationWithFallback TEST_DURATION")
+	assertEqual(t, time.Minute, value, "GetDurationWithFallback TEST_DURATION")
+}
+
+func TestGetTime(t *testing.T) {
+	setEnvForTest(t, "TEST_TIME", "2024-06-01T12:30:00Z")
+
+	value, err := GetTime("TEST_TIME", "")
+	assertNoError(t, err, "GetTime TEST_TIME")
+	assertEqual(t, time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC), value, "GetTime TEST_TIME")
+
+	_, err = GetTime("TEST_TIME", time.DateOnly)
+	assertError(t, err, "GetTime TEST_TIME wrong layout")
+
+	fallback := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	value, err = GetTimeWithFallback("TEST_TIME", time.DateOnly, fallback)
+	assertNoError(t, err, "GetTimeWithFallback TEST_TIME")
+	assertEqual(t, fallback, value, "GetTimeWithFallback TEST_TIME")
+}
@@ -0,0 +1,328 @@
+package env
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal returns the environment variables for a struct based on `env`
+// tags, using the same prefixes, separators and encodings as Unmarshal, so
+// that unmarshalling the result yields the same struct. Each field is written
+// to the primary key of its alias list. Nil pointers and fields read from a
+// file are omitted, and empty slices and maps unmarshal as nil. Dollar signs
+// in fields with `expand` are escaped as $$. Values that can't be
+// unmarshalled back, such as a pointer to an empty string, a slice of empty
+// strings or a struct slice element that writes no variables, return a
+// *MarshalError. Secret fields are
+// omitted too, and left at their defaults when unmarshalling the result,
+// unless WithRevealSecrets is given.
+func Marshal(data interface{}, opts ...Option) (Map, error) {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %v, expected a struct or a non-nil pointer to one", reflect.TypeOf(data))
+	}
+
+	// Copy the struct so that methods with pointer receivers can be called.
+	v := reflect.New(rv.Type()).Elem()
+	v.Set(rv)
+
+	vars := Map{}
+	d := newDecoder(opts)
+	if err := d.marshalStruct(v, d.prefix, "", vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// marshalStruct adds the variables for the fields of an addressable struct
+// to vars, with the given key prefix and field path.
+func (d *decoder) marshalStruct(v reflect.Value, prefix, path string, vars Map) error {
+	t := v.Type()
+
+	for _, fp := range d.plan(t).fields {
+		field := v.Field(fp.index)
+		fieldPath := path + fp.name
+
+		if !t.Field(fp.index).IsExported() {
+			continue
+		}
+
+		kind := d.kind(fp, field.Type())
+		if kind == fieldNested {
+			nestedPrefix := prefix
+			if fp.tag != "" {
+				nestedPrefix = prefix + fp.tag + "_"
+			}
+			if err := d.marshalStruct(field, nestedPrefix, fieldPath+".", vars); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tagOpts := fp.opts
+		if kind == fieldSkip || tagOpts.file {
+			continue
+		}
+		if tagOpts.sep == "" {
+			tagOpts.sep = d.sep
+		}
+		key := prefix + tagOpts.keys[0]
+
+		switch kind {
+		case fieldStructSlice:
+			for i := 0; i < field.Len(); i++ {
+				elemPrefix := key + "_" + strconv.Itoa(i) + "_"
+				elemPath := fieldPath + "[" + strconv.Itoa(i) + "]"
+				if err := d.marshalElem(field.Index(i), elemPrefix, elemPath, vars); err != nil {
+					return err
+				}
+			}
+		case fieldStructMap:
+			names := make(map[string]reflect.Value, field.Len())
+			iter := field.MapRange()
+			for iter.Next() {
+				name, err := d.formatValue(addressable(iter.Key()), tagOpts)
+				if err == nil && name == "" {
+					err = fmt.Errorf("empty map key")
+				}
+				if err != nil {
+					return &MarshalError{Key: key, FieldPath: fieldPath, Type: field.Type(), Err: err}
+				}
+				names[name] = iter.Value()
+			}
+			for _, name := range sortedKeys(names) {
+				elemPrefix := key + "_" + name + "_"
+				elemPath := fieldPath + "[" + name + "]"
+				if err := d.marshalElem(names[name], elemPrefix, elemPath, vars); err != nil {
+					return err
+				}
+			}
+		default:
+			if field.Kind() == reflect.Pointer && field.IsNil() || tagOpts.secret && !d.revealSecrets {
+				continue
+			}
+			value, err := d.formatValue(field, tagOpts)
+			if err != nil {
+				return &MarshalError{Key: key, FieldPath: fieldPath, Type: field.Type(), Err: err}
+			}
+			if value == "" && unmarshalsAsZero(field) {
+				err = fmt.Errorf("empty value would unmarshal as the zero %s", field.Type())
+				return &MarshalError{Key: key, FieldPath: fieldPath, Type: field.Type(), Err: err}
+			}
+			if tagOpts.expand {
+				value = strings.ReplaceAll(value, "$", "$$")
+			}
+			vars[key] = value
+		}
+	}
+
+	return nil
+}
+
+// unmarshalsAsZero reports whether a field written as an empty value would
+// lose its value, since Unmarshal leaves fields with empty values untouched.
+// Non-nil empty slices are documented to unmarshal as nil.
+func unmarshalsAsZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer:
+		return true
+	case reflect.Slice:
+		return v.Len() > 0
+	case reflect.Array:
+		return !isZeroValue(v)
+	}
+	return false
+}
+
+// marshalElem adds the variables for an element of a struct slice or map to
+// vars. An element that writes no variables would be missing when
+// unmarshalling the result, so it returns a *MarshalError.
+func (d *decoder) marshalElem(v reflect.Value, prefix, path string, vars Map) error {
+	n := len(vars)
+	if err := d.marshalStruct(structElem(v), prefix, path+".", vars); err != nil {
+		return err
+	}
+	if len(vars) == n {
+		err := fmt.Errorf("element has no variables to write")
+		return &MarshalError{Key: strings.TrimSuffix(prefix, "_"), FieldPath: path, Type: v.Type(), Err: err}
+	}
+	return nil
+}
+
+// structElem returns an addressable copy of a struct slice or map element,
+// dereferencing pointers, where nil pointers are treated as zero structs.
+func structElem(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.New(v.Type().Elem()).Elem()
+		}
+		return v.Elem()
+	}
+	return addressable(v)
+}
+
+// addressable returns v, or an addressable copy of it if it isn't.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// formatValue formats v as the string setValue parses back into the same
+// value, based on its type and the tag options.
+func (d *decoder) formatValue(v reflect.Value, opts tagOptions) (string, error) {
+	v = addressable(v)
+
+	if d.parser(v.Type()) != nil {
+		return formatText(v)
+	}
+
+	if isSecret(v.Type()) {
+		return d.formatValue(v.Addr().Interface().(secretValue).secretValue(), opts)
+	}
+
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	case timeType:
+		t := v.Interface().(time.Time)
+		value := formatTime(t, opts.layout)
+		if parsed, err := parseTime(value, opts.layout); err != nil || !parsed.Equal(t) {
+			return "", fmt.Errorf("%s does not hold the full time %s", value, t)
+		}
+		return value, nil
+	case locationType:
+		if v.IsNil() {
+			return "", fmt.Errorf("nil location")
+		}
+		return v.Interface().(*time.Location).String(), nil
+	}
+
+	if isUnmarshaler(v.Type()) {
+		return marshalValue(v)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		return d.formatList(v, opts)
+	case reflect.Map:
+		return d.formatMap(v, opts)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return "", fmt.Errorf("nil %s", v.Type())
+		}
+		return d.formatValue(v.Elem(), opts)
+	default:
+		return "", fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+}
+
+// formatList joins the elements of a slice or array with the separator,
+// which must not appear in any of them.
+func (d *decoder) formatList(v reflect.Value, opts tagOptions) (string, error) {
+	sep := opts.separator()
+	parts := make([]string, v.Len())
+	for i := range parts {
+		part, err := d.formatValue(v.Index(i), opts)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(part, sep) {
+			return "", fmt.Errorf("element %d contains the separator %q", i, sep)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// formatMap joins the entries of a map, sorted by key, in the format parsed
+// by setMap. Keys must not contain either separator, and values must not
+// contain the entry separator.
+func (d *decoder) formatMap(v reflect.Value, opts tagOptions) (string, error) {
+	sep, kvsep := opts.separator(), opts.keyValueSeparator()
+	entries := make(map[string]string, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := d.formatValue(iter.Key(), opts)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(key, sep) || strings.Contains(key, kvsep) {
+			return "", fmt.Errorf("map key contains the separator %q or %q", sep, kvsep)
+		}
+		value, err := d.formatValue(iter.Value(), opts)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(value, sep) {
+			return "", fmt.Errorf("map value for key %s contains the separator %q", key, sep)
+		}
+		entries[key] = value
+	}
+
+	parts := make([]string, 0, len(entries))
+	for _, key := range sortedKeys(entries) {
+		parts = append(parts, key+kvsep+entries[key])
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// marshalValue formats an addressable value through the interface matching
+// the one unmarshalValue decodes it with.
+func marshalValue(v reflect.Value) (string, error) {
+	ptr := v.Addr().Type()
+	switch {
+	case ptr.Implements(textUnmarshalerType):
+		return formatText(v)
+	case ptr.Implements(flagValueType):
+		return v.Addr().Interface().(flag.Value).String(), nil
+	default:
+		data, err := json.Marshal(v.Addr().Interface())
+		return string(data), err
+	}
+}
+
+// formatText formats an addressable value through encoding.TextMarshaler or
+// fmt.Stringer, for types decoded by UnmarshalText or a registered parser.
+func formatText(v reflect.Value) (string, error) {
+	switch m := v.Addr().Interface().(type) {
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		return string(text), err
+	case fmt.Stringer:
+		return m.String(), nil
+	}
+	return "", fmt.Errorf("%s implements neither encoding.TextMarshaler nor fmt.Stringer", v.Type())
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
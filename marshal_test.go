@@ -0,0 +1,265 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=5432"`
+	}
+
+	type Config struct {
+		Name     string              `env:"NAME|APP_NAME"`
+		Debug    bool                `env:"DEBUG"`
+		Port     uint16              `env:"PORT,default=8080"`
+		Ratio    float32             `env:"RATIO"`
+		Timeout  time.Duration       `env:"TIMEOUT"`
+		Hosts    []string            `env:"HOSTS,sep=;"`
+		Labels   map[string]string   `env:"LABELS"`
+		Database Database            `env:"DB"`
+		Replicas []Database          `env:"REPLICA"`
+		Shards   map[string]Database `env:"SHARD"`
+		Optional *int                `env:"OPTIONAL"`
+		Secret   string              `env:"SECRET_FILE,file"`
+		Ignored  string
+	}
+
+	cfg := Config{
+		Name:     "app",
+		Debug:    true,
+		Ratio:    0.1,
+		Timeout:  90 * time.Second,
+		Hosts:    []string{"a,b", "c"},
+		Labels:   map[string]string{"team": "core", "env": "prod"},
+		Database: Database{Host: "db.internal"},
+		Replicas: []Database{{Host: "r0"}, {Host: "r1", Port: 1}},
+		Shards:   map[string]Database{"EU_WEST": {Host: "eu"}},
+		Secret:   "hunter2",
+		Ignored:  "ignored",
+	}
+
+	vars, err := Marshal(&cfg)
+	assertNoError(t, err, "Marshal")
+
+	assertEqual(t, Map{
+		"NAME":               "app",
+		"DEBUG":              "true",
+		"PORT":               "0",
+		"RATIO":              "0.1",
+		"TIMEOUT":            "1m30s",
+		"HOSTS":              "a,b;c",
+		"LABELS":             "env:prod,team:core",
+		"DB_HOST":            "db.internal",
+		"DB_PORT":            "0",
+		"REPLICA_0_HOST":     "r0",
+		"REPLICA_0_PORT":     "0",
+		"REPLICA_1_HOST":     "r1",
+		"REPLICA_1_PORT":     "1",
+		"SHARD_EU_WEST_HOST": "eu",
+		"SHARD_EU_WEST_PORT": "0",
+	}, vars, "vars")
+
+	var decoded Config
+	err = UnmarshalFrom(vars, &decoded)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	cfg.Secret, cfg.Ignored = "", ""
+	assertEqual(t, cfg, decoded, "round trip")
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	type Endpoint struct {
+		URL string `env:"URL"`
+	}
+
+	type Config struct {
+		Int8      int8               `env:"INT8"`
+		Int64     int64              `env:"INT64"`
+		Uint64    uint64             `env:"UINT64"`
+		Float64   float64            `env:"FLOAT64"`
+		Ints      []int              `env:"INTS"`
+		Array     [3]float64         `env:"ARRAY"`
+		Pointer   *string            `env:"POINTER"`
+		Pointers  []*int             `env:"POINTERS"`
+		Weights   map[string]float64 `env:"WEIGHTS"`
+		Date      time.Time          `env:"DATE,layout=DateOnly"`
+		Instant   time.Time          `env:"INSTANT"`
+		Location  *time.Location     `env:"LOCATION"`
+		IP        net.IP             `env:"IP"`
+		Flag      testFlag           `env:"FLAG"`
+		JSON      testJSON           `env:"JSON"`
+		Endpoints []*Endpoint        `env:"ENDPOINT"`
+		Named     map[int]*Endpoint  `env:"NAMED"`
+	}
+
+	str := "value"
+	one, two := 1, 2
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	assertNoError(t, err, "LoadLocation")
+
+	cfg := Config{
+		Int8:      -128,
+		Int64:     -9223372036854775808,
+		Uint64:    18446744073709551615,
+		Float64:   1.0 / 3,
+		Ints:      []int{1, -2, 3},
+		Array:     [3]float64{0.5, 1e-9, 3},
+		Pointer:   &str,
+		Pointers:  []*int{&one, &two},
+		Weights:   map[string]float64{"a": 0.25, "b": 4},
+		Date:      time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Instant:   time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+		Location:  loc,
+		IP:        net.ParseIP("10.0.0.1"),
+		Flag:      testFlag{values: []string{"a", "b"}},
+		JSON:      testJSON{Name: "json"},
+		Endpoints: []*Endpoint{{URL: "http://a"}, {URL: "http://b"}},
+		Named:     map[int]*Endpoint{7: {URL: "http://seven"}},
+	}
+
+	vars, err := Marshal(cfg)
+	assertNoError(t, err, "Marshal")
+
+	var decoded Config
+	err = UnmarshalFrom(vars, &decoded)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	if !reflect.DeepEqual(cfg, decoded) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v\nvars %v", decoded, cfg, vars)
+	}
+
+	type Elem struct {
+		A *string `env:"A"`
+	}
+
+	empty := ""
+	lossy := []struct {
+		name string
+		data interface{}
+		key  string
+	}{
+		{
+			name: "EmptyPointer",
+			data: struct {
+				S *string `env:"S"`
+			}{S: &empty},
+			key: "S",
+		},
+		{
+			name: "TruncatedTime",
+			data: struct {
+				D time.Time `env:"D,layout=DateOnly"`
+			}{D: time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)},
+			key: "D",
+		},
+		{
+			name: "EmptyStrings",
+			data: struct {
+				S []string `env:"S"`
+			}{S: []string{""}},
+			key: "S",
+		},
+		{
+			name: "EmptyPointers",
+			data: struct {
+				S []*string `env:"S"`
+			}{S: []*string{&empty}},
+			key: "S",
+		},
+		{
+			name: "EmptySliceElement",
+			data: struct {
+				L []Elem `env:"L"`
+			}{L: []Elem{{}, {A: &str}}},
+			key: "L_0",
+		},
+		{
+			name: "EmptyMapElement",
+			data: struct {
+				M map[string]Elem `env:"M"`
+			}{M: map[string]Elem{"a": {}}},
+			key: "M_a",
+		},
+	}
+
+	for _, tt := range lossy {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.data)
+			var marshalErr *MarshalError
+			if !errors.As(err, &marshalErr) {
+				t.Fatalf("expected *MarshalError, got %v", err)
+			}
+			assertEqual(t, tt.key, marshalErr.Key, "Key")
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{
+			name: "Nil",
+			data: nil,
+			want: "cannot marshal <nil>, expected a struct or a non-nil pointer to one",
+		},
+		{
+			name: "NonStruct",
+			data: new(int),
+			want: "cannot marshal *int, expected a struct or a non-nil pointer to one",
+		},
+		{
+			name: "Separator",
+			data: struct {
+				Hosts []string `env:"HOSTS"`
+			}{Hosts: []string{"a,b"}},
+			want: `cannot marshal Hosts into environment variable HOSTS: element 0 contains the separator ","`,
+		},
+		{
+			name: "MapKeySeparator",
+			data: struct {
+				Labels map[string]string `env:"LABELS"`
+			}{Labels: map[string]string{"a:b": "c"}},
+			want: `cannot marshal Labels into environment variable LABELS: map key contains the separator "," or ":"`,
+		},
+		{
+			name: "NoMarshaler",
+			data: struct {
+				Level testLevel `env:"LEVEL"`
+			}{},
+			want: "cannot marshal Level into environment variable LEVEL: env.testLevel implements neither encoding.TextMarshaler nor fmt.Stringer",
+		},
+		{
+			name: "Unsupported",
+			data: struct {
+				Func func() `env:"FUNC"`
+			}{},
+			want: "cannot marshal Func into environment variable FUNC: unsupported kind func",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.data)
+			assertError(t, err, "Marshal")
+			assertEqual(t, tt.want, err.Error(), "error")
+		})
+	}
+
+	var marshalErr *MarshalError
+	_, err := Marshal(struct {
+		Hosts []string `env:"HOSTS"`
+	}{Hosts: []string{"a,b"}})
+	if !errors.As(err, &marshalErr) {
+		t.Fatalf("expected *MarshalError, got %v", err)
+	}
+	assertEqual(t, "HOSTS", marshalErr.Key, "Key")
+}
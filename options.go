@@ -0,0 +1,83 @@
+package env
+
+// Option configures how variables are unmarshalled and marshalled.
+type Option func(*decoder)
+
+// WithSource sets the source variables are read from, which defaults to the
+// environment of the current process.
+func WithSource(src Source) Option {
+	return func(d *decoder) {
+		d.src = src
+	}
+}
+
+// WithFailFast stops unmarshalling at the first invalid or missing variable,
+// rather than reporting all of them together.
+func WithFailFast() Option {
+	return func(d *decoder) {
+		d.failFast = true
+	}
+}
+
+// WithIntegerLiterals parses integers using Go integer literal syntax, which
+// accepts base prefixes such as 0x1F, 0o755 and 0b101, and digits separated
+// by underscores such as 1_000_000.
+func WithIntegerLiterals() Option {
+	return func(d *decoder) {
+		d.intLiterals = true
+	}
+}
+
+// WithTagName sets the name of the struct tag fields are read from, which
+// defaults to "env".
+func WithTagName(name string) Option {
+	return func(d *decoder) {
+		d.tagName = name
+	}
+}
+
+// WithPrefix prepends a prefix to the keys of all fields, such as "APP_".
+func WithPrefix(prefix string) Option {
+	return func(d *decoder) {
+		d.prefix = prefix
+	}
+}
+
+// WithSeparator sets the separator between slice elements and map entries
+// for fields without a `sep` tag option, which defaults to ",".
+func WithSeparator(sep string) Option {
+	return func(d *decoder) {
+		d.sep = sep
+	}
+}
+
+// WithStrict validates the tags and field types of the struct before
+// unmarshalling, returning a *TagError for unknown tag options and fields of
+// unsupported types, rather than only failing once a variable is set. After
+// unmarshalling, variables sharing the prefix of a struct that no field reads
+// are reported as *UnusedVariableError.
+func WithStrict() Option {
+	return func(d *decoder) {
+		d.strict = true
+	}
+}
+
+// WithExpandAllowlist restricts the references of fields with `expand` to
+// the fields of the struct and the given variables, so that other variables
+// in the environment can't be interpolated into them.
+func WithExpandAllowlist(names ...string) Option {
+	return func(d *decoder) {
+		d.expandAllow = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			d.expandAllow[name] = struct{}{}
+		}
+	}
+}
+
+// WithRevealSecrets makes Marshal write the values of secret fields, which
+// are otherwise omitted.
+func WithRevealSecrets() Option {
+	return func(d *decoder) {
+		d.revealSecrets = true
+	}
+}
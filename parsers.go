@@ -0,0 +1,94 @@
+package env
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"reflect"
+	"sync"
+)
+
+// parserFunc parses a string into a value of the type it was registered for.
+type parserFunc func(string) (reflect.Value, error)
+
+// parsers holds the globally registered parsers, keyed by reflect.Type.
+var parsers sync.Map
+
+// RegisterParser registers a function that parses values of type T. It is
+// used by every Unmarshal for fields, slice elements and map values of that
+// type, and takes precedence over the built-in parsing. Registering a parser
+// for a type that already has one replaces it.
+func RegisterParser[T any](fn func(string) (T, error)) {
+	parsers.Store(typeOf[T](), newParserFunc(fn))
+	resetPlans()
+}
+
+// WithParser registers a function that parses values of type T for a single
+// Unmarshal, taking precedence over parsers registered with RegisterParser.
+func WithParser[T any](fn func(string) (T, error)) Option {
+	return func(d *decoder) {
+		if d.parsers == nil {
+			d.parsers = make(map[reflect.Type]parserFunc)
+		}
+		d.parsers[typeOf[T]()] = newParserFunc(fn)
+	}
+}
+
+func newParserFunc[T any](fn func(string) (T, error)) parserFunc {
+	return func(value string) (reflect.Value, error) {
+		result, err := fn(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&result).Elem(), nil
+	}
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// parser returns the parser for the given type, if one is registered with
+// the decoder or globally.
+func (d *decoder) parser(t reflect.Type) parserFunc {
+	if fn, ok := d.parsers[t]; ok {
+		return fn
+	}
+	if fn, ok := parsers.Load(t); ok {
+		return fn.(parserFunc)
+	}
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+// isUnmarshaler reports whether a pointer to the given type implements
+// encoding.TextUnmarshaler, json.Unmarshaler or flag.Value.
+func isUnmarshaler(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(textUnmarshalerType) || p.Implements(jsonUnmarshalerType) || p.Implements(flagValueType)
+}
+
+// unmarshalValue decodes the value into an addressable field through the
+// first interface it implements, in order of encoding.TextUnmarshaler,
+// flag.Value and json.Unmarshaler. Values passed to UnmarshalJSON that aren't
+// valid JSON are encoded as a JSON string first.
+func unmarshalValue(field reflect.Value, value string) error {
+	switch target := field.Addr().Interface().(type) {
+	case encoding.TextUnmarshaler:
+		return target.UnmarshalText([]byte(value))
+	case flag.Value:
+		return target.Set(value)
+	case json.Unmarshaler:
+		data := []byte(value)
+		if !json.Valid(data) {
+			data, _ = json.Marshal(value)
+		}
+		return target.UnmarshalJSON(data)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	case "error":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type testFlag struct {
+	values []string
+}
+
+func (f *testFlag) String() string {
+	return strings.Join(f.values, "+")
+}
+
+func (f *testFlag) Set(value string) error {
+	f.values = strings.Split(value, "+")
+	return nil
+}
+
+type testJSON struct {
+	Name string `json:"name"`
+}
+
+func (j *testJSON) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		j.Name = name
+		return nil
+	}
+	type plain testJSON
+	return json.Unmarshal(data, (*plain)(j))
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func parseTestPoint(value string) (testPoint, error) {
+	var p testPoint
+	_, err := fmt.Sscanf(value, "%d:%d", &p.X, &p.Y)
+	return p, err
+}
+
+type testID string
+
+func TestUnmarshalInterfaces(t *testing.T) {
+	type Config struct {
+		Level  testLevel   `env:"LEVEL"`
+		Levels []testLevel `env:"LEVELS"`
+		Flag   testFlag    `env:"FLAG"`
+		Plain  testJSON    `env:"PLAIN"`
+		Object testJSON    `env:"OBJECT"`
+		IP     net.IP      `env:"IP"`
+		IPs    []net.IP    `env:"IPS"`
+	}
+
+	src := Map{
+		"LEVEL":  "info",
+		"LEVELS": "debug,error",
+		"FLAG":   "a+b",
+		"PLAIN":  "plain",
+		"OBJECT": `{"name":"object"}`,
+		"IP":     "10.0.0.1",
+		"IPS":    "10.0.0.1,::1",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, testLevel(1), cfg.Level, "Level")
+	assertEqual(t, []testLevel{0, 2}, cfg.Levels, "Levels")
+	assertEqual(t, []string{"a", "b"}, cfg.Flag.values, "Flag")
+	assertEqual(t, "plain", cfg.Plain.Name, "Plain")
+	assertEqual(t, "object", cfg.Object.Name, "Object")
+	assertEqual(t, "10.0.0.1", cfg.IP.String(), "IP")
+	assertEqual(t, 2, len(cfg.IPs), "IPs")
+	assertEqual(t, "::1", cfg.IPs[1].String(), "IPs")
+}
+
+func TestUnmarshalInterfaceError(t *testing.T) {
+	var cfg struct {
+		Level testLevel `env:"LEVEL"`
+	}
+
+	err := UnmarshalFrom(Map{"LEVEL": "verbose"}, &cfg)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	assertEqual(t, `unknown level "verbose"`, parseErr.Err.Error(), "Err")
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser(parseTestPoint)
+	t.Cleanup(func() {
+		parsers.Delete(typeOf[testPoint]())
+		resetPlans()
+	})
+
+	type Config struct {
+		Origin testPoint   `env:"ORIGIN"`
+		Path   []testPoint `env:"PATH"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{"ORIGIN": "1:2", "PATH": "1:2,3:4"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, testPoint{1, 2}, cfg.Origin, "Origin")
+	assertEqual(t, []testPoint{{1, 2}, {3, 4}}, cfg.Path, "Path")
+
+	err = UnmarshalFrom(Map{"ORIGIN": "nowhere"}, &cfg)
+	assertError(t, err, "UnmarshalFrom invalid")
+}
+
+func TestWithParser(t *testing.T) {
+	RegisterParser(func(value string) (testID, error) {
+		return testID("global-" + value), nil
+	})
+	t.Cleanup(func() {
+		parsers.Delete(typeOf[testID]())
+		resetPlans()
+	})
+
+	type Config struct {
+		ID     testID    `env:"ID"`
+		Origin testPoint `env:"ORIGIN"`
+	}
+
+	src := Map{"ID": "42", "ORIGIN": "5:6"}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg,
+		WithParser(parseTestPoint),
+		WithParser(func(value string) (testID, error) {
+			return testID("local-" + value), nil
+		}),
+	)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, testID("local-42"), cfg.ID, "ID")
+	assertEqual(t, testPoint{5, 6}, cfg.Origin, "Origin")
+
+	cfg = Config{}
+	err = UnmarshalFrom(Map{"ID": "42"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, testID("global-42"), cfg.ID, "ID")
+}
@@ -0,0 +1,229 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// plans caches the structPlan of each struct type and tag name, so that tags
+// are parsed once per type rather than on every Unmarshal.
+var plans sync.Map
+
+// planKey identifies a cached structPlan.
+type planKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+// fieldKind describes how a struct field is unmarshalled.
+type fieldKind int
+
+const (
+	fieldSkip        fieldKind = iota // untagged field
+	fieldValue                        // single variable
+	fieldNested                       // nested struct with an optional prefix
+	fieldStructSlice                  // slice of structs from indexed variables
+	fieldStructMap                    // map of structs from named variables
+)
+
+// structPlan holds the parsed tags of a struct type.
+type structPlan struct {
+	fields      []fieldPlan
+	expandsOnce sync.Once
+	expands     bool // whether the struct or any struct within it expands a field
+	refsOnce    sync.Once
+	refs        map[string]*fieldRef // fields by key, for expansion, built on first use
+}
+
+// fieldPlan holds the parsed tag of a struct field.
+type fieldPlan struct {
+	index int
+	name  string
+	tag   string
+	opts  tagOptions
+	kind  fieldKind // kind with only globally registered parsers
+}
+
+// plan returns the plan of a struct type for the decoder's tag name.
+func (d *decoder) plan(t reflect.Type) *structPlan {
+	return planFor(t, d.tagName)
+}
+
+// planFor returns the cached plan of a struct type using the given tag name,
+// building it on first use.
+func planFor(t reflect.Type, tagName string) *structPlan {
+	key := planKey{t: t, tagName: tagName}
+	if plan, ok := plans.Load(key); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := plans.LoadOrStore(key, buildPlan(t, tagName))
+	return plan.(*structPlan)
+}
+
+func buildPlan(t reflect.Type, tagName string) *structPlan {
+	d := &decoder{}
+	plan := &structPlan{fields: make([]fieldPlan, 0, t.NumField())}
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		tag := fieldType.Tag.Get(tagName)
+		field := fieldPlan{
+			index: i,
+			name:  fieldType.Name,
+			tag:   tag,
+			opts:  parseTag(tag),
+			kind:  d.kindOf(fieldType.Type, tag),
+		}
+		if containsSecret(fieldType.Type) {
+			field.opts.secret = true
+		}
+		plan.fields = append(plan.fields, field)
+	}
+	return plan
+}
+
+// expands reports whether a struct type or any struct within it has a field
+// with `expand`, so that unmarshalling it needs scopes to resolve references.
+func (d *decoder) expands(t reflect.Type) bool {
+	plan := d.plan(t)
+	plan.expandsOnce.Do(func() {
+		plan.expands = hasExpand(t, d.tagName, make(map[reflect.Type]struct{}))
+	})
+	return plan.expands
+}
+
+// hasExpand reports whether a struct type or any struct within it has a field
+// with `expand`. Fields are searched by their cached kinds, which nest at
+// least the structs any decoder does.
+func hasExpand(t reflect.Type, tagName string, visited map[reflect.Type]struct{}) bool {
+	if _, ok := visited[t]; ok {
+		return false
+	}
+	visited[t] = struct{}{}
+
+	for _, fp := range planFor(t, tagName).fields {
+		if fp.opts.expand {
+			return true
+		}
+		fieldType := t.Field(fp.index).Type
+		switch fp.kind {
+		case fieldNested:
+			if hasExpand(fieldType, tagName, visited) {
+				return true
+			}
+		case fieldStructSlice, fieldStructMap:
+			if hasExpand(structElemType(fieldType), tagName, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kindOf returns how a field of the given type and tag is unmarshalled.
+func (d *decoder) kindOf(t reflect.Type, tag string) fieldKind {
+	switch {
+	case isNestedStruct(t) && d.parsers[t] == nil:
+		return fieldNested
+	case tag == "":
+		return fieldSkip
+	case d.isStructSlice(t):
+		return fieldStructSlice
+	case d.isStructMap(t):
+		return fieldStructMap
+	default:
+		return fieldValue
+	}
+}
+
+// kind returns how a field is unmarshalled by the decoder, which differs from
+// the cached kind only when the decoder has parsers of its own.
+func (d *decoder) kind(field fieldPlan, t reflect.Type) fieldKind {
+	if len(d.parsers) == 0 {
+		return field.kind
+	}
+	return d.kindOf(t, field.tag)
+}
+
+// resetPlans clears the cached plans, which depend on the registered parsers.
+func resetPlans() {
+	plans.Range(func(key, _ interface{}) bool {
+		plans.Delete(key)
+		return true
+	})
+}
+
+// validate checks the tags and field types of a struct type, returning a
+// *TagError for each unknown tag option, missing variable name and field of
+// a type that can't be unmarshalled.
+func (d *decoder) validate(t reflect.Type) error {
+	return errors.Join(d.validateStruct(t, "")...)
+}
+
+func (d *decoder) validateStruct(t reflect.Type, path string) []error {
+	var errs []error
+	for _, fp := range d.plan(t).fields {
+		fieldType := t.Field(fp.index).Type
+		fieldPath := path + fp.name
+
+		kind := d.kind(fp, fieldType)
+		switch kind {
+		case fieldSkip:
+			continue
+		case fieldNested:
+			errs = append(errs, d.validateStruct(fieldType, fieldPath+".")...)
+			continue
+		}
+
+		for _, opt := range fp.opts.unknown {
+			errs = append(errs, &TagError{FieldPath: fieldPath, Msg: unknownOption(opt)})
+		}
+		if fp.opts.keys[0] == "" {
+			errs = append(errs, &TagError{FieldPath: fieldPath, Msg: "missing variable name"})
+		}
+
+		switch kind {
+		case fieldStructSlice:
+			errs = append(errs, d.validateStruct(structElemType(fieldType), fieldPath+"["+indexPlaceholder+"].")...)
+		case fieldStructMap:
+			if !d.supports(fieldType.Key()) {
+				errs = append(errs, &TagError{FieldPath: fieldPath, Msg: fmt.Sprintf("unsupported map key type %s", fieldType.Key())})
+			}
+			errs = append(errs, d.validateStruct(structElemType(fieldType), fieldPath+"["+namePlaceholder+"].")...)
+		case fieldValue:
+			if !d.supports(fieldType) {
+				errs = append(errs, &TagError{FieldPath: fieldPath, Msg: fmt.Sprintf("unsupported type %s", fieldType)})
+			}
+		}
+	}
+	return errs
+}
+
+// supports reports whether values of the given type can be parsed by setValue.
+func (d *decoder) supports(t reflect.Type) bool {
+	if d.parser(t) != nil || isUnmarshaler(t) {
+		return true
+	}
+	if isSecret(t) {
+		return d.supports(secretElem(t))
+	}
+	switch t {
+	case durationType, timeType, locationType:
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Pointer:
+		return d.supports(t.Elem())
+	case reflect.Map:
+		return d.supports(t.Key()) && d.supports(t.Elem())
+	}
+	return false
+}
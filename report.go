@@ -0,0 +1,110 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Origin describes where the value of a field came from.
+type Origin int
+
+const (
+	// OriginUnset means no value was found and the field was left untouched.
+	OriginUnset Origin = iota
+	// OriginSource means the value was read from the source, such as the
+	// environment.
+	OriginSource
+	// OriginDefault means the value came from the `default` or `fallback`
+	// tag option.
+	OriginDefault
+	// OriginStruct means the value was already set on the struct before it
+	// was unmarshalled.
+	OriginStruct
+)
+
+func (o Origin) String() string {
+	switch o {
+	case OriginSource:
+		return "source"
+	case OriginDefault:
+		return "default"
+	case OriginStruct:
+		return "struct"
+	default:
+		return "unset"
+	}
+}
+
+// Report describes where the values of an unmarshalled struct came from. It
+// never contains the values themselves.
+type Report struct {
+	Fields []FieldReport
+}
+
+// FieldReport describes where the value of a single field came from.
+type FieldReport struct {
+	Path     string // path of the field in the struct, e.g. "Database.Port"
+	Key      string // variable that matched, or the primary key if none did
+	Origin   Origin // where the value came from
+	Source   string // name of the source or layer the variable was read from
+	File     string // path the value was read from, for `file` fields
+	Expanded bool   // whether `expand` substituted any variables
+	Secret   bool   // whether the field is secret
+}
+
+// Field returns the report for the field at the given path.
+func (r *Report) Field(path string) (FieldReport, bool) {
+	for _, f := range r.Fields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return FieldReport{}, false
+}
+
+// String returns a human-readable summary of the report, one field per line.
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, f := range r.Fields {
+		fmt.Fprintf(&b, "%s: %s from %s", f.Path, f.Key, f.Origin)
+		if f.Source != "" {
+			fmt.Fprintf(&b, " (%s)", f.Source)
+		}
+		if f.File != "" {
+			fmt.Fprintf(&b, ", read from file %s", f.File)
+		}
+		if f.Expanded {
+			b.WriteString(", expanded")
+		}
+		if f.Secret {
+			b.WriteString(", secret")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (r *Report) add(f FieldReport) {
+	if r != nil {
+		r.Fields = append(r.Fields, f)
+	}
+}
+
+// describeSource returns the name of the source, or layer of a layered
+// source, that the given key was read from, if it has one.
+func describeSource(src Source, key string) string {
+	if layered, ok := src.(*LayeredSource); ok {
+		_, i, ok := layered.LookupLayer(key)
+		if !ok {
+			return ""
+		}
+		if name := describeSource(layered.Layer(i), key); name != "" {
+			return name
+		}
+		return fmt.Sprintf("layer %d", i)
+	}
+	if s, ok := src.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalWithReport(t *testing.T) {
+	type DatabaseConfig struct {
+		Host     string `env:"HOST,default=localhost"`
+		Port     int    `env:"PORT|DB_PORT,fallback=3306"`
+		Password string `env:"PASSWORD,file"`
+		Name     string `env:"NAME"`
+		Unset    string `env:"UNSET"`
+	}
+
+	type Config struct {
+		Address  string         `env:"ADDRESS,default=${DATABASE_HOST}:80,expand"`
+		Database DatabaseConfig `env:"DATABASE"`
+	}
+
+	secret := filepath.Join(t.TempDir(), "password")
+	err := os.WriteFile(secret, []byte("hunter2"), 0o600)
+	assertNoError(t, err, "WriteFile")
+
+	src := Layered(
+		Named("defaults", Map{"DATABASE_HOST": "db.internal"}),
+		Map{"DATABASE_DB_PORT": "5432", "DATABASE_PASSWORD": secret},
+	)
+
+	cfg := Config{Database: DatabaseConfig{Name: "app"}}
+	report, err := UnmarshalWithReport(&cfg, WithSource(src))
+	assertNoError(t, err, "UnmarshalWithReport")
+	assertEqual(t, "hunter2", cfg.Database.Password, "Password")
+
+	expected := []FieldReport{
+		{Path: "Address", Key: "ADDRESS", Origin: OriginDefault, Expanded: true},
+		{Path: "Database.Host", Key: "DATABASE_HOST", Origin: OriginSource, Source: "defaults"},
+		{Path: "Database.Port", Key: "DATABASE_DB_PORT", Origin: OriginSource, Source: "layer 1"},
+		{Path: "Database.Password", Key: "DATABASE_PASSWORD", Origin: OriginSource, Source: "layer 1", File: secret},
+		{Path: "Database.Name", Key: "DATABASE_NAME", Origin: OriginStruct},
+		{Path: "Database.Unset", Key: "DATABASE_UNSET", Origin: OriginUnset},
+	}
+	assertEqual(t, expected, report.Fields, "Report")
+
+	field, ok := report.Field("Database.Port")
+	assertEqual(t, true, ok, "Field")
+	assertEqual(t, "DATABASE_DB_PORT", field.Key, "Field")
+
+	_, ok = report.Field("Missing")
+	assertEqual(t, false, ok, "Field missing")
+
+	if strings.Contains(report.String(), "hunter2") {
+		t.Errorf("report leaks secret value: %s", report)
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := &Report{Fields: []FieldReport{
+		{Path: "Port", Key: "PORT", Origin: OriginSource, Source: "environment"},
+		{Path: "Key", Key: "KEY", Origin: OriginSource, File: "/run/secrets/key"},
+		{Path: "URL", Key: "URL", Origin: OriginDefault, Expanded: true},
+	}}
+
+	expected := "Port: PORT from source (environment)\n" +
+		"Key: KEY from source, read from file /run/secrets/key\n" +
+		"URL: URL from default, expanded\n"
+
+	assertEqual(t, expected, report.String(), "String")
+}
+
+func TestOriginString(t *testing.T) {
+	assertEqual(t, "unset", OriginUnset.String(), "OriginUnset")
+	assertEqual(t, "source", OriginSource.String(), "OriginSource")
+	assertEqual(t, "default", OriginDefault.String(), "OriginDefault")
+	assertEqual(t, "struct", OriginStruct.String(), "OriginStruct")
+}
@@ -0,0 +1,309 @@
+package env
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// scope is a struct being unmarshalled, whose fields and those of its
+// ancestors can be referenced by name when expanding a field.
+type scope struct {
+	prefix string
+	path   string
+	t      reflect.Type
+	parent *scope
+}
+
+// fieldRef is a field that expansion can resolve a reference to, relative to
+// the struct whose plan holds it.
+type fieldRef struct {
+	opts   tagOptions
+	path   string        // path of the field within the struct
+	nested []nestedScope // structs nested with a prefix that hold the field, outermost first
+}
+
+// nestedScope is a struct nested with a prefix, relative to the struct whose
+// plan holds a fieldRef.
+type nestedScope struct {
+	prefix string
+	path   string
+	t      reflect.Type
+}
+
+// prefix returns the prefix of the field's keys within its struct.
+func (r *fieldRef) prefix() string {
+	return nestedPrefix(r.nested)
+}
+
+// nestedPrefix returns the prefix of the innermost of the nested structs.
+func nestedPrefix(nested []nestedScope) string {
+	if len(nested) == 0 {
+		return ""
+	}
+	return nested[len(nested)-1].prefix
+}
+
+// scope returns the scope of the struct holding the field, given the scope of
+// the struct whose plan holds it.
+func (r *fieldRef) scope(s *scope) *scope {
+	inner := s
+	for _, n := range r.nested {
+		inner = &scope{prefix: s.prefix + n.prefix, path: s.path + n.path, t: n.t, parent: inner}
+	}
+	return inner
+}
+
+// expansion is a field being expanded, linked to the expansion of the field
+// that referenced it.
+type expansion struct {
+	key    string
+	parent *expansion
+}
+
+// resolvedRef is the value of a referenced field, before it is parsed.
+type resolvedRef struct {
+	value string
+	found bool
+}
+
+// lookupField returns the value of a field from the source, the file it
+// names, or its default, before any expansion, and records where it came
+// from in entry. found reports whether the variable is set in the source.
+func (d *decoder) lookupField(entry *FieldReport, opts tagOptions, prefix string) (value string, found bool, err error) {
+	key, value, found := findFieldValue(d.src, opts.keys, prefix)
+
+	entry.Key = key
+	if found {
+		entry.Origin = OriginSource
+		entry.Source = describeSource(d.src, key)
+	} else {
+		entry.Key = prefix + opts.keys[0]
+	}
+
+	if opts.file && found {
+		fileContent, err := readFileContent(value)
+		if err != nil {
+			return "", false, &FileError{Key: key, FieldPath: entry.Path, Path: value, Err: err}
+		}
+		entry.File = value
+		value = fileContent
+	}
+
+	if !found && opts.fallback != "" {
+		value = opts.fallback
+		entry.Origin = OriginDefault
+	}
+	return value, found, nil
+}
+
+// expandField expands the value of the field with the given prefixed key,
+// resolving references within scope s. Expanding a field that is already
+// being expanded, as listed by parent, returns a *CycleError, and referencing
+// a secret field from a field that isn't secret returns a *ReferenceError.
+func (d *decoder) expandField(value, key string, secret bool, s *scope, parent *expansion) (string, error) {
+	for e := parent; e != nil; e = e.parent {
+		if e.key == key {
+			return "", &CycleError{Chain: cycle(parent, e, key)}
+		}
+	}
+
+	current := &expansion{key: key, parent: parent}
+	e := &expander{lookup: func(name string) (string, bool, error) {
+		return d.lookupRef(name, secret, s, current)
+	}}
+	return e.expand(value)
+}
+
+// cycle returns the keys of the expansions from start to end, in the order
+// they were referenced, followed by key.
+func cycle(end, start *expansion, key string) []string {
+	var chain []string
+	for e := end; e != start.parent; e = e.parent {
+		chain = append(chain, e.key)
+	}
+	slices.Reverse(chain)
+	return append(chain, key)
+}
+
+// lookupRef resolves a reference to name from scope s. The field keyed name,
+// prefixed by the scope or the closest of its ancestors, is used even if it
+// has no value. References to names no field declares fall back to the
+// unprefixed variable in the source unless expansion is restricted to an
+// allowlist without it, or the variable is the prefixed key of a secret
+// field.
+func (d *decoder) lookupRef(name string, secret bool, s *scope, from *expansion) (string, bool, error) {
+	for s := s; s != nil; s = s.parent {
+		ref := d.refs(s.t)[name]
+		if ref == nil {
+			continue
+		}
+		if ref.opts.secret && !secret {
+			return "", false, &ReferenceError{Name: name, Key: s.prefix + ref.prefix() + ref.opts.keys[0], Secret: true}
+		}
+
+		resolved, err := d.resolveRef(ref, s, from)
+		if err != nil {
+			return "", false, err
+		}
+		return resolved.value, resolved.found || resolved.value != "", nil
+	}
+
+	if !secret && d.isSecretKey(name, s) {
+		return "", false, &ReferenceError{Name: name, Key: name, Secret: true}
+	}
+	if d.expandAllow != nil {
+		if _, ok := d.expandAllow[name]; !ok {
+			return "", false, &ReferenceError{Name: name, Key: name}
+		}
+	}
+	value, ok := d.src.Lookup(name)
+	return value, ok, nil
+}
+
+// isSecretKey reports whether name is the key of a secret field, prefixed by
+// scope s or one of its ancestors.
+func (d *decoder) isSecretKey(name string, s *scope) bool {
+	for ; s != nil; s = s.parent {
+		if rest, ok := strings.CutPrefix(name, s.prefix); ok && d.isSecretField(s.t, rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretField reports whether key, relative to a struct type, is the key of
+// a secret field of the struct, its nested structs, or the elements of its
+// slices and maps of structs.
+func (d *decoder) isSecretField(t reflect.Type, key string) bool {
+	if ref := d.refs(t)[key]; ref != nil && ref.opts.secret {
+		return true
+	}
+	return d.isSecretElemField(t, key, "")
+}
+
+// isSecretElemField reports whether key is the key of a secret field of an
+// element of one of the slices or maps of structs in a struct type, whose
+// keys are preceded by prefix. Indexes and names are matched the same way
+// findIndexes and findNames discover them.
+func (d *decoder) isSecretElemField(t reflect.Type, key, prefix string) bool {
+	for _, fp := range d.plan(t).fields {
+		fieldType := t.Field(fp.index).Type
+		kind := d.kind(fp, fieldType)
+		if kind == fieldNested {
+			nestedPrefix := prefix
+			if fp.tag != "" {
+				nestedPrefix = prefix + fp.tag + "_"
+			}
+			if d.isSecretElemField(fieldType, key, nestedPrefix) {
+				return true
+			}
+			continue
+		}
+		if kind != fieldStructSlice && kind != fieldStructMap {
+			continue
+		}
+
+		elemType := structElemType(fieldType)
+		for _, k := range fp.opts.keys {
+			rest, ok := strings.CutPrefix(key, prefix+k+"_")
+			if !ok {
+				continue
+			}
+			var elemKey string
+			if kind == fieldStructSlice {
+				_, elemKey, ok = cutIndex(rest)
+			} else {
+				_, elemKey, ok = cutName(rest, longestFirst(d.structKeys(elemType, "")))
+			}
+			if ok && d.isSecretField(elemType, elemKey) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveRef returns the value of a field referenced from scope s, expanded
+// if the field has `expand`. The values of fields that are expanded or read
+// from files are kept for further references.
+func (d *decoder) resolveRef(ref *fieldRef, s *scope, from *expansion) (resolvedRef, error) {
+	prefix := s.prefix + ref.prefix()
+	var entry FieldReport
+	if !ref.opts.expand && !ref.opts.file {
+		value, found, err := d.lookupField(&entry, ref.opts, prefix)
+		return resolvedRef{value: value, found: found}, err
+	}
+
+	key := prefix + ref.opts.keys[0]
+	if resolved, ok := d.resolved[key]; ok {
+		return resolved, nil
+	}
+
+	entry.Path = s.path + ref.path
+	value, found, err := d.lookupField(&entry, ref.opts, prefix)
+	if err == nil && ref.opts.expand {
+		value, err = d.expandField(value, key, ref.opts.secret, ref.scope(s), from)
+	}
+	if err != nil {
+		return resolvedRef{}, err
+	}
+
+	if d.resolved == nil {
+		d.resolved = make(map[string]resolvedRef)
+	}
+	d.resolved[key] = resolvedRef{value: value, found: found}
+	return d.resolved[key], nil
+}
+
+// refs returns the fields of a struct type and its nested structs by key,
+// relative to the struct. The table is cached in the plan of the type, or in
+// the decoder when it has parsers of its own, which may change which fields
+// are nested structs.
+func (d *decoder) refs(t reflect.Type) map[string]*fieldRef {
+	if len(d.parsers) > 0 {
+		refs, ok := d.refTables[t]
+		if !ok {
+			if d.refTables == nil {
+				d.refTables = make(map[reflect.Type]map[string]*fieldRef)
+			}
+			refs = d.buildRefs(t)
+			d.refTables[t] = refs
+		}
+		return refs
+	}
+
+	plan := d.plan(t)
+	plan.refsOnce.Do(func() { plan.refs = d.buildRefs(t) })
+	return plan.refs
+}
+
+func (d *decoder) buildRefs(t reflect.Type) map[string]*fieldRef {
+	refs := make(map[string]*fieldRef)
+	d.collectRefs(refs, t, "", nil)
+	return refs
+}
+
+// collectRefs adds the fields of a struct to refs. The first field with a key
+// wins, searching nested structs depth-first in field order.
+func (d *decoder) collectRefs(refs map[string]*fieldRef, t reflect.Type, path string, nested []nestedScope) {
+	for _, fp := range d.plan(t).fields {
+		fieldType := t.Field(fp.index).Type
+		switch d.kind(fp, fieldType) {
+		case fieldNested:
+			inner := nested
+			if fp.tag != "" {
+				prefix := nestedPrefix(nested) + fp.tag + "_"
+				inner = append(nested[:len(nested):len(nested)], nestedScope{prefix: prefix, path: path + fp.name + ".", t: fieldType})
+			}
+			d.collectRefs(refs, fieldType, path+fp.name+".", inner)
+		case fieldValue:
+			ref := &fieldRef{opts: fp.opts, path: path + fp.name, nested: nested}
+			for _, key := range fp.opts.keys {
+				if _, ok := refs[ref.prefix()+key]; !ok {
+					refs[ref.prefix()+key] = ref
+				}
+			}
+		}
+	}
+}
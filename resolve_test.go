@@ -0,0 +1,250 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandResolvesFields(t *testing.T) {
+	type Database struct {
+		URL  string `env:"URL,expand,default=postgres://${USER}@${HOST}:${PORT}/${NAME}"`
+		Host string `env:"HOST,default=localhost"`
+		Port int    `env:"PORT,default=5432"`
+		User string `env:"USER,file"`
+	}
+
+	type Config struct {
+		Port     int      `env:"PORT,default=8080"`
+		Name     string   `env:"NAME,default=app"`
+		Database Database `env:"DB"`
+		Addr     string   `env:"ADDR,expand,default=${HOST:-0.0.0.0}:${PORT}"`
+		DBAddr   string   `env:"DB_ADDR,expand,default=${DB_HOST}:${DB_PORT}"`
+	}
+
+	userFile := filepath.Join(t.TempDir(), "user")
+	assertNoError(t, os.WriteFile(userFile, []byte("admin"), 0o600), "WriteFile")
+
+	src := Map{
+		"HOST":    "example.com",
+		"DB_PORT": "6543",
+		"DB_USER": userFile,
+	}
+
+	var cfg Config
+	assertNoError(t, UnmarshalFrom(src, &cfg), "UnmarshalFrom")
+	assertEqual(t, "postgres://admin@localhost:6543/app", cfg.Database.URL, "Database.URL")
+	assertEqual(t, "example.com:8080", cfg.Addr, "Addr")
+	assertEqual(t, "localhost:6543", cfg.DBAddr, "DBAddr")
+}
+
+func TestExpandResolvesRecursively(t *testing.T) {
+	type Config struct {
+		URL  string `env:"URL,expand,default=${BASE}/api"`
+		Base string `env:"BASE,expand,default=${SCHEME}://${HOST}"`
+		Host string `env:"HOST,expand,default=${NAME}.internal"`
+		Name string `env:"NAME"`
+	}
+
+	src := Map{"NAME": "svc", "SCHEME": "https"}
+
+	var cfg Config
+	assertNoError(t, UnmarshalFrom(src, &cfg), "UnmarshalFrom")
+	assertEqual(t, "https://svc.internal/api", cfg.URL, "URL")
+	assertEqual(t, "https://svc.internal", cfg.Base, "Base")
+	assertEqual(t, "svc.internal", cfg.Host, "Host")
+
+	// Values from the source are expanded too.
+	src["BASE"] = "http://${HOST}:8080"
+	assertNoError(t, UnmarshalFrom(src, &cfg), "UnmarshalFrom")
+	assertEqual(t, "http://svc.internal:8080/api", cfg.URL, "URL from source")
+}
+
+func TestExpandResolvesPrefixedFields(t *testing.T) {
+	type Upstream struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=80"`
+		Addr string `env:"ADDR,expand,default=${HOST}:${PORT}@${REGION}"`
+	}
+
+	type Config struct {
+		Region    string     `env:"REGION,default=eu"`
+		Upstreams []Upstream `env:"UPSTREAM"`
+	}
+
+	src := Map{
+		"APP_UPSTREAM_0_HOST": "a",
+		"APP_UPSTREAM_1_HOST": "b",
+		"APP_UPSTREAM_1_PORT": "8080",
+		"APP_REGION":          "us",
+		"REGION":              "ignored",
+	}
+
+	var cfg Config
+	assertNoError(t, UnmarshalFrom(src, &cfg, WithPrefix("APP_")), "UnmarshalFrom")
+	assertEqual(t, "a:80@us", cfg.Upstreams[0].Addr, "Upstreams[0].Addr")
+	assertEqual(t, "b:8080@us", cfg.Upstreams[1].Addr, "Upstreams[1].Addr")
+}
+
+func TestExpandResolvesUnsetFields(t *testing.T) {
+	type Database struct {
+		URL  string `env:"URL,expand,default=${HOST}:${PORT}"`
+		Host string `env:"HOST,default=dbhost"`
+		Port string `env:"PORT"`
+	}
+
+	type Config struct {
+		Port     string   `env:"PORT,default=80"`
+		Database Database `env:"DB"`
+	}
+
+	// The nested PORT shadows the top-level one, even though it has no value.
+	var cfg Config
+	assertNoError(t, UnmarshalFrom(Map{"PORT": "8080"}, &cfg), "UnmarshalFrom")
+	assertEqual(t, "dbhost:", cfg.Database.URL, "Database.URL")
+	assertEqual(t, "8080", cfg.Port, "Port")
+
+	assertNoError(t, UnmarshalFrom(Map{"DB_PORT": "5432"}, &cfg), "UnmarshalFrom")
+	assertEqual(t, "dbhost:5432", cfg.Database.URL, "Database.URL with DB_PORT")
+}
+
+func TestExpandCycle(t *testing.T) {
+	type Config struct {
+		A string `env:"A,expand,default=${B}"`
+		B string `env:"B,expand,default=x${C}"`
+		C string `env:"C,expand,default=${A}"`
+		D string `env:"D,expand,default=${D}"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{}, &cfg)
+	want := "invalid value for environment variable A: expansion cycle: A -> B -> C -> A\n" +
+		"invalid value for environment variable B: expansion cycle: B -> C -> A -> B\n" +
+		"invalid value for environment variable C: expansion cycle: C -> A -> B -> C\n" +
+		"invalid value for environment variable D: expansion cycle: D -> D"
+	assertEqual(t, want, err.Error(), "error")
+
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected *CycleError, got %v", err)
+	}
+	assertEqual(t, []string{"A", "B", "C", "A"}, cycleErr.Chain, "Chain")
+
+	// Breaking the cycle from the source resolves every field.
+	err = UnmarshalFrom(Map{"C": "c", "D": "d"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, Config{A: "xc", B: "xc", C: "c", D: "d"}, cfg, "Config")
+}
+
+func TestExpandAllowlist(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST"`
+	}
+
+	type Config struct {
+		Port     int      `env:"PORT,default=8080"`
+		Database Database `env:"DB"`
+		Addr     string   `env:"ADDR,expand,default=${DB_HOST:-localhost}:${PORT}"`
+		Home     string   `env:"HOME_DIR,expand,default=${HOME}/app"`
+		URL      string   `env:"URL,expand"`
+	}
+
+	src := Map{
+		"HOME":                  "/home/app",
+		"URL":                   "https://${AWS_SECRET_ACCESS_KEY}@example.com",
+		"AWS_SECRET_ACCESS_KEY": "key",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg, WithExpandAllowlist("HOME"))
+	assertEqual(t, "invalid value for environment variable URL: reference ${AWS_SECRET_ACCESS_KEY} is not allowed", err.Error(), "error")
+	assertEqual(t, "localhost:8080", cfg.Addr, "Addr")
+	assertEqual(t, "/home/app/app", cfg.Home, "Home")
+
+	var refErr *ReferenceError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("expected *ReferenceError, got %v", err)
+	}
+	assertEqual(t, "AWS_SECRET_ACCESS_KEY", refErr.Name, "Name")
+	assertEqual(t, false, refErr.Secret, "Secret")
+
+	err = UnmarshalFrom(src, &cfg, WithExpandAllowlist())
+	assertEqual(t, "invalid value for environment variable HOME_DIR: reference ${HOME} is not allowed\n"+
+		"invalid value for environment variable URL: reference ${AWS_SECRET_ACCESS_KEY} is not allowed", err.Error(), "empty allowlist")
+
+	err = UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom without allowlist")
+	assertEqual(t, "https://key@example.com", cfg.URL, "URL")
+}
+
+func TestExpandSecret(t *testing.T) {
+	type Database struct {
+		User     string `env:"USER,default=app"`
+		Password string `env:"PASSWORD,secret"`
+		DSN      string `env:"DSN,secret,expand,default=${USER}:${PASSWORD}@db"`
+		URL      string `env:"URL,expand,default=postgres://${DSN}"`
+		Safe     string `env:"SAFE,expand,default=${USER}@db"`
+	}
+
+	type Config struct {
+		Database Database `env:"DB"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{"DB_PASSWORD": "hunter2"}, &cfg)
+	assertEqual(t, "invalid value for environment variable DB_URL: reference ${DSN} to secret DB_DSN from a field that is not secret", err.Error(), "error")
+	assertEqual(t, "app:hunter2@db", cfg.Database.DSN, "DSN")
+	assertEqual(t, "app@db", cfg.Database.Safe, "Safe")
+
+	var refErr *ReferenceError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("expected *ReferenceError, got %v", err)
+	}
+	assertEqual(t, "DB_DSN", refErr.Key, "Key")
+	assertEqual(t, true, refErr.Secret, "Secret")
+
+	// References to fields of nested structs report the field's own key.
+	type App struct {
+		Database struct {
+			Password string `env:"PASSWORD,secret"`
+		} `env:"DB"`
+		URL string `env:"URL,expand,default=postgres://${DB_PASSWORD}@db"`
+	}
+
+	var app App
+	err = UnmarshalFrom(Map{"DB_PASSWORD": "hunter2"}, &app)
+	assertEqual(t, "invalid value for environment variable URL: reference ${DB_PASSWORD} to secret DB_PASSWORD from a field that is not secret", err.Error(), "nested error")
+
+	// Secret fields can't be read from the source by their prefixed keys.
+	type Prefixed struct {
+		Pass string `env:"PASS,secret"`
+		URL  string `env:"URL,expand"`
+	}
+
+	var prefixed Prefixed
+	err = UnmarshalFrom(Map{"APP_PASS": "hunter2", "APP_URL": "u:${APP_PASS}"}, &prefixed, WithPrefix("APP_"))
+	assertEqual(t, "invalid value for environment variable APP_URL: reference ${APP_PASS} to secret APP_PASS from a field that is not secret", err.Error(), "prefixed error")
+	assertEqual(t, "", prefixed.URL, "prefixed URL")
+
+	// Nor by the keys of the elements of slices and maps of structs.
+	type Upstream struct {
+		Token string `env:"TOKEN,secret"`
+	}
+
+	type Upstreams struct {
+		List []Upstream          `env:"UP"`
+		Map  map[string]Upstream `env:"DB"`
+		URL  string              `env:"URL,expand"`
+	}
+
+	var ups Upstreams
+	err = UnmarshalFrom(Map{"UP_0_TOKEN": "hunter2", "URL": "http://${UP_0_TOKEN}"}, &ups)
+	assertEqual(t, "invalid value for environment variable URL: reference ${UP_0_TOKEN} to secret UP_0_TOKEN from a field that is not secret", err.Error(), "slice error")
+	assertEqual(t, "", ups.URL, "slice URL")
+
+	ups = Upstreams{}
+	err = UnmarshalFrom(Map{"DB_X_TOKEN": "hunter2", "URL": "http://${DB_X_TOKEN}"}, &ups)
+	assertEqual(t, "invalid value for environment variable URL: reference ${DB_X_TOKEN} to secret DB_X_TOKEN from a field that is not secret", err.Error(), "map error")
+	assertEqual(t, "", ups.URL, "map URL")
+}
@@ -0,0 +1,150 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// redacted is printed in place of secret values.
+const redacted = "[REDACTED]"
+
+// Secret holds a value that is never printed, whether it is formatted with
+// fmt, encoded as JSON or logged with log/slog, all of which print
+// [REDACTED] instead. Fields of type Secret are unmarshalled like fields of
+// type T, and treated as if they had the `secret` tag option.
+type Secret[T any] struct {
+	value T
+}
+
+// NewSecret returns a Secret holding value.
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value: value}
+}
+
+// Value returns the value held by the secret.
+func (s Secret[T]) Value() T {
+	return s.value
+}
+
+func (s Secret[T]) String() string {
+	return redacted
+}
+
+func (s Secret[T]) GoString() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter, printing [REDACTED] for every verb.
+func (s Secret[T]) Format(f fmt.State, verb rune) {
+	_, _ = f.Write([]byte(redacted))
+}
+
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// LogValue implements slog.LogValuer.
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// secretValue is implemented by pointers to Secret, giving access to the
+// value they hold for unmarshalling and marshalling.
+type secretValue interface {
+	secretValue() reflect.Value
+}
+
+func (s *Secret[T]) secretValue() reflect.Value {
+	return reflect.ValueOf(&s.value).Elem()
+}
+
+var secretValueType = reflect.TypeOf((*secretValue)(nil)).Elem()
+
+// isSecret reports whether t is a Secret type.
+func isSecret(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(secretValueType)
+}
+
+// secretElem returns the type of the value held by a Secret type.
+func secretElem(t reflect.Type) reflect.Type {
+	return reflect.New(t).Interface().(secretValue).secretValue().Type()
+}
+
+// containsSecret reports whether t is a Secret type, or a pointer, slice,
+// array or map whose elements are, at any depth.
+func containsSecret(t reflect.Type) bool {
+	for {
+		if isSecret(t) {
+			return true
+		}
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+// withoutSecrets returns t with the Secret types in it replaced by the types
+// they hold, such as *string for *Secret[string]. Types without secrets are
+// returned as is, keeping their names.
+func withoutSecrets(t reflect.Type) reflect.Type {
+	if !containsSecret(t) {
+		return t
+	}
+	if isSecret(t) {
+		return withoutSecrets(secretElem(t))
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return reflect.PointerTo(withoutSecrets(t.Elem()))
+	case reflect.Slice:
+		return reflect.SliceOf(withoutSecrets(t.Elem()))
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), withoutSecrets(t.Elem()))
+	default:
+		return reflect.MapOf(t.Key(), withoutSecrets(t.Elem()))
+	}
+}
+
+// redactedError replaces an error about a secret value, whose message may
+// contain the value, while still matching the errors it wraps with errors.Is.
+type redactedError struct {
+	err error
+	typ reflect.Type
+}
+
+func (e *redactedError) Error() string {
+	return fmt.Sprintf("cannot parse %s as %s", redacted, e.typ)
+}
+
+func (e *redactedError) Is(target error) bool {
+	return errors.Is(e.err, target)
+}
+
+// parseError returns a *ParseError for the value of a field. For secret
+// fields, the value and any error that may contain it are redacted, while
+// errors about references, which only contain variable names, are kept. The
+// message of an *ExpansionError is redacted if it was expanded from other
+// references.
+func parseError(key, path string, t reflect.Type, value string, secret bool, err error) error {
+	if secret {
+		var expansionErr *ExpansionError
+		var cycleErr *CycleError
+		var refErr *ReferenceError
+		switch {
+		case errors.As(err, &expansionErr):
+			if expansionErr.expanded {
+				err = &ExpansionError{Name: expansionErr.Name, Msg: redacted, Empty: expansionErr.Empty}
+			}
+		case !errors.As(err, &cycleErr) && !errors.As(err, &refErr):
+			err = &redactedError{err: err, typ: t}
+		}
+		value = redacted
+	}
+	return &ParseError{Key: key, FieldPath: path, Type: t, Value: value, Err: err}
+}
@@ -0,0 +1,202 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecretRedacts(t *testing.T) {
+	s := NewSecret("hunter2")
+	assertEqual(t, "hunter2", s.Value(), "Value")
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d", "%10s"} {
+		assertEqual(t, redacted, fmt.Sprintf(format, s), format)
+	}
+	assertEqual(t, redacted, s.String(), "String")
+	assertEqual(t, redacted, s.GoString(), "GoString")
+
+	type Config struct {
+		Password Secret[string]
+		PIN      *Secret[int]
+	}
+	pin := NewSecret(1234)
+	cfg := Config{Password: s, PIN: &pin}
+
+	assertEqual(t, "{Password:[REDACTED] PIN:[REDACTED]}", fmt.Sprintf("%+v", cfg), "struct")
+
+	data, err := json.Marshal(cfg)
+	assertNoError(t, err, "json.Marshal")
+	assertEqual(t, `{"Password":"[REDACTED]","PIN":"[REDACTED]"}`, string(data), "JSON")
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "password", s, "pin", pin)
+	if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "1234") {
+		t.Fatalf("expected log output to be redacted, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "password=[REDACTED] pin=[REDACTED]") {
+		t.Fatalf("expected redacted attributes, got %s", buf.String())
+	}
+}
+
+func TestUnmarshalSecret(t *testing.T) {
+	type Config struct {
+		Password Secret[string]   `env:"PASSWORD,required"`
+		PIN      Secret[int]      `env:"PIN,default=1234"`
+		Token    *Secret[string]  `env:"TOKEN"`
+		Keys     []Secret[string] `env:"KEYS"`
+		APIKey   string           `env:"API_KEY,sensitive"`
+	}
+
+	src := Map{"PASSWORD": "hunter2", "TOKEN": "t0k3n", "KEYS": "a,b", "API_KEY": "key"}
+
+	dec, err := NewDecoder[Config](WithSource(src))
+	assertNoError(t, err, "NewDecoder")
+
+	cfg, err := dec.Decode()
+	assertNoError(t, err, "Decode")
+	assertEqual(t, "hunter2", cfg.Password.Value(), "Password")
+	assertEqual(t, 1234, cfg.PIN.Value(), "PIN")
+	assertEqual(t, "t0k3n", cfg.Token.Value(), "Token")
+	assertEqual(t, "b", cfg.Keys[1].Value(), "Keys")
+
+	assertEqual(t, true, parseTag("API_KEY,sensitive").secret, "sensitive")
+}
+
+func TestUnmarshalSecretErrors(t *testing.T) {
+	type Config struct {
+		PIN    Secret[int] `env:"PIN"`
+		Port   int         `env:"PORT,secret"`
+		Debug  bool        `env:"DEBUG,secret"`
+		Labels []int       `env:"LABELS,secret"`
+		DSN    string      `env:"DSN,secret,expand"`
+	}
+
+	src := Map{
+		"PIN":    "hunter2",
+		"PORT":   "99999999999999999999",
+		"DEBUG":  "hunter2",
+		"LABELS": "1,hunter2",
+		"DSN":    "${hunter2",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	want := "invalid value for environment variable PIN: cannot parse [REDACTED] as env.Secret[int]\n" +
+		"invalid value for environment variable PORT: cannot parse [REDACTED] as int\n" +
+		"invalid value for environment variable DEBUG: cannot parse [REDACTED] as bool\n" +
+		"invalid value for environment variable LABELS: cannot parse [REDACTED] as []int\n" +
+		"invalid value for environment variable DSN: cannot parse [REDACTED] as string"
+	assertEqual(t, want, err.Error(), "error")
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	assertEqual(t, redacted, parseErr.Value, "Value")
+
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected error to match strconv.ErrRange, got %v", err)
+	}
+	var rangeErr *RangeError
+	if errors.As(err, &rangeErr) {
+		t.Errorf("expected the *RangeError holding the value to be hidden, got %v", rangeErr)
+	}
+
+	// Collections of secrets are secret as a whole.
+	type Collections struct {
+		Keys   []Secret[int]          `env:"KEYS"`
+		Tokens map[string]Secret[int] `env:"TOKENS"`
+	}
+
+	var collections Collections
+	err = UnmarshalFrom(Map{"KEYS": "12,hunter2", "TOKENS": "a:hunter2"}, &collections)
+	want = "invalid value for environment variable KEYS: cannot parse [REDACTED] as []env.Secret[int]\n" +
+		"invalid value for environment variable TOKENS: cannot parse [REDACTED] as map[string]env.Secret[int]"
+	assertEqual(t, want, err.Error(), "collections")
+
+	// Errors about references only contain names, and are kept.
+	err = UnmarshalFrom(Map{"DSN": "${HOST:?must be set}"}, &cfg)
+	assertEqual(t, "invalid value for environment variable DSN: HOST: must be set", err.Error(), "reference")
+
+	// Unless their messages are expanded from references to other values.
+	type Expanded struct {
+		PW string `env:"PW,secret"`
+		D  string `env:"D,secret,expand"`
+	}
+
+	var expanded Expanded
+	err = UnmarshalFrom(Map{"PW": "hunter2", "D": "${NOPE:?$PW}"}, &expanded)
+	assertEqual(t, "invalid value for environment variable D: NOPE: [REDACTED]", err.Error(), "expanded message")
+}
+
+func TestMarshalSecret(t *testing.T) {
+	type Config struct {
+		Password Secret[string]            `env:"PASSWORD"`
+		APIKey   string                    `env:"API_KEY,secret"`
+		Keys     []Secret[string]          `env:"KEYS"`
+		Tokens   map[string]Secret[string] `env:"TOKENS"`
+		Host     string                    `env:"HOST"`
+	}
+
+	in := Config{
+		Password: NewSecret("hunter2"),
+		APIKey:   "key",
+		Keys:     []Secret[string]{NewSecret("k1"), NewSecret("k2")},
+		Tokens:   map[string]Secret[string]{"a": NewSecret("s1")},
+		Host:     "localhost",
+	}
+
+	vars, err := Marshal(in)
+	assertNoError(t, err, "Marshal")
+	assertEqual(t, Map{"HOST": "localhost"}, vars, "omitted")
+
+	vars, err = Marshal(in, WithRevealSecrets())
+	assertNoError(t, err, "Marshal with secrets")
+	assertEqual(t, Map{"PASSWORD": "hunter2", "API_KEY": "key", "KEYS": "k1,k2", "TOKENS": "a:s1", "HOST": "localhost"}, vars, "revealed")
+
+	var out Config
+	assertNoError(t, UnmarshalFrom(vars, &out), "UnmarshalFrom")
+	assertEqual(t, in, out, "round trip")
+}
+
+func TestSecretExporters(t *testing.T) {
+	type Config struct {
+		Password Secret[string]  `env:"PASSWORD,default=changeme,desc=Database password"`
+		Token    *Secret[string] `env:"TOKEN"`
+		Keys     []Secret[int]   `env:"KEYS"`
+	}
+
+	usage := Usage(&Config{})
+	if strings.Contains(usage, "changeme") || !strings.Contains(usage, secretPlaceholder) {
+		t.Errorf("expected usage to hide the default, got:\n%s", usage)
+	}
+
+	var example bytes.Buffer
+	assertNoError(t, GenerateExample(&Config{}, &example), "GenerateExample")
+	if strings.Contains(example.String(), "changeme") {
+		t.Errorf("expected example to hide the default, got:\n%s", example.String())
+	}
+
+	types := make(map[string]string)
+	for _, line := range strings.Split(usage, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			types[fields[0]] = fields[1]
+		}
+	}
+	assertEqual(t, "string", types["PASSWORD"], "PASSWORD type")
+	assertEqual(t, "*string", types["TOKEN"], "TOKEN type")
+	assertEqual(t, "[]int", types["KEYS"], "KEYS type")
+
+	report, err := UnmarshalWithReport(&Config{}, WithSource(Map{"PASSWORD": "hunter2"}))
+	assertNoError(t, err, "UnmarshalWithReport")
+	want := "Password: PASSWORD from source, secret\n" +
+		"Token: TOKEN from unset, secret\n" +
+		"Keys: KEYS from unset, secret\n"
+	assertEqual(t, want, report.String(), "report")
+}
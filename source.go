@@ -0,0 +1,157 @@
+package env
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source is a provider of environment variables.
+type Source interface {
+	// Lookup returns the value of the variable named by key and a boolean
+	// indicating whether the variable is present in the source.
+	Lookup(key string) (string, bool)
+
+	// Keys returns the names of all variables present in the source.
+	Keys() []string
+}
+
+// OS is a Source backed by the environment of the current process.
+var OS Source = osSource{}
+
+type osSource struct{}
+
+func (osSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osSource) Keys() []string {
+	return Environ(os.Environ()).Keys()
+}
+
+func (osSource) String() string {
+	return "environment"
+}
+
+// Map is a Source backed by an in-memory map of variables.
+type Map map[string]string
+
+// Lookup returns the value of the variable named by key.
+func (m Map) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Keys returns the sorted names of all variables in the map.
+func (m Map) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Environ is a Source backed by a slice of "key=value" strings, in the same
+// format as returned by os.Environ. When a key is present more than once, the
+// last occurrence wins.
+type Environ []string
+
+// Lookup returns the value of the variable named by key.
+func (e Environ) Lookup(key string) (string, bool) {
+	for i := len(e) - 1; i >= 0; i-- {
+		k, v, ok := strings.Cut(e[i], "=")
+		if ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Keys returns the sorted, de-duplicated names of all variables in the slice.
+func (e Environ) Keys() []string {
+	seen := make(map[string]struct{}, len(e))
+	keys := make([]string, 0, len(e))
+	for _, kv := range e {
+		k, _, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			continue
+		}
+		if _, dup := seen[k]; dup {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LayeredSource is a Source that resolves variables through a stack of
+// sources, where later layers take precedence over earlier ones.
+type LayeredSource struct {
+	layers []Source
+}
+
+// Layered returns a Source that combines the given sources in order of
+// increasing precedence, e.g. defaults, then files, then the environment:
+//
+//	src := env.Layered(defaults, dotenv, env.OS, overrides)
+func Layered(sources ...Source) *LayeredSource {
+	return &LayeredSource{layers: sources}
+}
+
+// Lookup returns the value of the variable named by key from the highest
+// layer it is present in.
+func (l *LayeredSource) Lookup(key string) (string, bool) {
+	value, _, ok := l.LookupLayer(key)
+	return value, ok
+}
+
+// LookupLayer returns the value of the variable named by key along with the
+// index of the layer it was found in.
+func (l *LayeredSource) LookupLayer(key string) (string, int, bool) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if value, ok := l.layers[i].Lookup(key); ok {
+			return value, i, true
+		}
+	}
+	return "", -1, false
+}
+
+// Layer returns the source at the given index.
+func (l *LayeredSource) Layer(index int) Source {
+	return l.layers[index]
+}
+
+// Keys returns the sorted names of all variables present in any layer.
+func (l *LayeredSource) Keys() []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, layer := range l.layers {
+		for _, key := range layer.Keys() {
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Named wraps a Source with a name describing where its variables come from,
+// such as a file path, which is returned by its String method.
+func Named(name string, src Source) Source {
+	return namedSource{Source: src, name: name}
+}
+
+type namedSource struct {
+	Source
+	name string
+}
+
+func (n namedSource) String() string {
+	return n.name
+}
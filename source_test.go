@@ -0,0 +1,145 @@
+package env
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMapSource(t *testing.T) {
+	src := Map{"B": "2", "A": "1"}
+
+	value, ok := src.Lookup("A")
+	assertEqual(t, true, ok, "Lookup A")
+	assertEqual(t, "1", value, "Lookup A")
+
+	_, ok = src.Lookup("C")
+	assertEqual(t, false, ok, "Lookup C")
+
+	assertEqual(t, []string{"A", "B"}, src.Keys(), "Keys")
+}
+
+func TestEnvironSource(t *testing.T) {
+	src := Environ{"A=1", "B=x=y", "A=2", "EMPTY=", "INVALID"}
+
+	value, ok := src.Lookup("A")
+	assertEqual(t, true, ok, "Lookup A")
+	assertEqual(t, "2", value, "Lookup A last wins")
+
+	value, ok = src.Lookup("B")
+	assertEqual(t, true, ok, "Lookup B")
+	assertEqual(t, "x=y", value, "Lookup B")
+
+	value, ok = src.Lookup("EMPTY")
+	assertEqual(t, true, ok, "Lookup EMPTY")
+	assertEqual(t, "", value, "Lookup EMPTY")
+
+	_, ok = src.Lookup("INVALID")
+	assertEqual(t, false, ok, "Lookup INVALID")
+
+	assertEqual(t, []string{"A", "B", "EMPTY"}, src.Keys(), "Keys")
+}
+
+func TestOSSource(t *testing.T) {
+	setEnvForTest(t, "TEST_OS_SOURCE", "value")
+
+	value, ok := OS.Lookup("TEST_OS_SOURCE")
+	assertEqual(t, true, ok, "Lookup")
+	assertEqual(t, "value", value, "Lookup")
+
+	found := false
+	for _, key := range OS.Keys() {
+		if key == "TEST_OS_SOURCE" {
+			found = true
+		}
+	}
+	assertEqual(t, true, found, "Keys contains TEST_OS_SOURCE")
+}
+
+func TestUnmarshalFrom(t *testing.T) {
+	type DatabaseConfig struct {
+		Host string `env:"HOST,default=localhost"`
+		Port int    `env:"PORT"`
+	}
+
+	type Config struct {
+		Debug    bool           `env:"DEBUG"`
+		Address  string         `env:"ADDRESS,default=${HOST}:${PORT},expand"`
+		Database DatabaseConfig `env:"DATABASE"`
+	}
+
+	src := Map{
+		"DEBUG":         "true",
+		"HOST":          "example.com",
+		"PORT":          "80",
+		"DATABASE_PORT": "5432",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	expected := Config{
+		Debug:   true,
+		Address: "example.com:80",
+		Database: DatabaseConfig{
+			Host: "localhost",
+			Port: 5432,
+		},
+	}
+
+	assertEqual(t, expected, cfg, "UnmarshalFrom")
+}
+
+func TestLayeredSource(t *testing.T) {
+	defaults := Named("defaults", Map{"HOST": "localhost", "PORT": "8080"})
+	dotenv := Named(".env", Map{"PORT": "9090", "USER": "dotenv"})
+	overrides := Map{"USER": "override"}
+
+	src := Layered(defaults, dotenv, overrides)
+
+	value, layer, ok := src.LookupLayer("HOST")
+	assertEqual(t, true, ok, "LookupLayer HOST")
+	assertEqual(t, "localhost", value, "LookupLayer HOST")
+	assertEqual(t, 0, layer, "LookupLayer HOST layer")
+	assertEqual(t, "defaults", src.Layer(layer).(fmt.Stringer).String(), "Layer name")
+
+	value, layer, ok = src.LookupLayer("PORT")
+	assertEqual(t, true, ok, "LookupLayer PORT")
+	assertEqual(t, "9090", value, "LookupLayer PORT")
+	assertEqual(t, 1, layer, "LookupLayer PORT layer")
+
+	value, ok = src.Lookup("USER")
+	assertEqual(t, true, ok, "Lookup USER")
+	assertEqual(t, "override", value, "Lookup USER")
+
+	_, layer, ok = src.LookupLayer("MISSING")
+	assertEqual(t, false, ok, "LookupLayer MISSING")
+	assertEqual(t, -1, layer, "LookupLayer MISSING layer")
+
+	assertEqual(t, []string{"HOST", "PORT", "USER"}, src.Keys(), "Keys")
+}
+
+func TestUnmarshalFromLayered(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT|LEGACY_PORT"`
+		User string `env:"USER,default=nobody"`
+	}
+
+	src := Layered(
+		Map{"HOST": "localhost", "PORT": "8080"},
+		Map{"LEGACY_PORT": "9090"},
+	)
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	expected := Config{
+		Host: "localhost",
+		Port: 9090, // the alias in the higher layer wins
+		User: "nobody",
+	}
+
+	assertEqual(t, expected, cfg, "UnmarshalFromLayered")
+}
@@ -0,0 +1,117 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tagOptionNames lists the options recognized by parseTag, for suggesting
+// corrections to unknown ones.
+var tagOptionNames = []string{
+	"default", "fallback", "required", "file", "expand", "secret", "sensitive",
+	"layout", "sep", "kvsep", "desc", "enum",
+}
+
+// unknownOption describes an unknown tag option, suggesting the known option
+// closest to its name.
+func unknownOption(opt string) string {
+	name, _, _ := strings.Cut(opt, "=")
+	if suggestion := suggest(name, tagOptionNames); suggestion != "" {
+		return fmt.Sprintf("unknown option %q, did you mean %q?", opt, suggestion)
+	}
+	return fmt.Sprintf("unknown option %q", opt)
+}
+
+// consume records the keys of a field as used, for reporting unused
+// variables in strict mode.
+func (d *decoder) consume(prefix string, keys []string) {
+	if !d.strict {
+		return
+	}
+	if d.consumed == nil {
+		d.consumed = make(map[string]struct{})
+	}
+	for _, key := range keys {
+		d.consumed[prefix+key] = struct{}{}
+	}
+}
+
+// addPrefix records a prefix of a struct, whose variables are reported in
+// strict mode when no field uses them.
+func (d *decoder) addPrefix(prefix string) {
+	if !d.strict || prefix == "" {
+		return
+	}
+	if d.prefixes == nil {
+		d.prefixes = make(map[string]struct{})
+	}
+	d.prefixes[prefix] = struct{}{}
+}
+
+// unused returns an UnusedVariableError for each variable in the source that
+// shares the prefix of a struct, but isn't used by any field.
+func (d *decoder) unused() []error {
+	if len(d.prefixes) == 0 {
+		return nil
+	}
+
+	consumed := make([]string, 0, len(d.consumed))
+	for key := range d.consumed {
+		consumed = append(consumed, key)
+	}
+	sort.Strings(consumed)
+
+	var errs []error
+	for _, key := range d.src.Keys() {
+		if _, ok := d.consumed[key]; ok || !d.hasPrefix(key) {
+			continue
+		}
+		errs = append(errs, &UnusedVariableError{Key: key, Suggestion: suggest(key, consumed)})
+	}
+	return errs
+}
+
+func (d *decoder) hasPrefix(key string) bool {
+	for prefix := range d.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the candidate closest to s by Levenshtein distance, if it
+// is close enough to likely be a typo, or an empty string otherwise. Ties are
+// broken by the order of the candidates.
+func suggest(s string, candidates []string) string {
+	best, bestDistance := "", max(2, len(s)/4)+1
+	for _, candidate := range candidates {
+		if distance := levenshtein(s, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single-byte insertions, deletions and
+// substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,103 @@
+package env
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"required", "required", 0},
+		{"requried", "required", 2},
+		{"defualt", "default", 2},
+		{"DATABASE_PASWORD", "DATABASE_PASSWORD", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		assertEqual(t, tt.want, levenshtein(tt.a, tt.b), tt.a+" -> "+tt.b)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	assertEqual(t, "required", suggest("requird", tagOptionNames), "requird")
+	assertEqual(t, "sep", suggest("sepp", tagOptionNames), "sepp")
+	assertEqual(t, "", suggest("color", tagOptionNames), "color")
+	assertEqual(t, "", suggest("DATABASE_TIMEOUT", []string{"DATABASE_HOST"}), "DATABASE_TIMEOUT")
+}
+
+func TestUnmarshalStrictUnused(t *testing.T) {
+	type Database struct {
+		Host     string `env:"HOST"`
+		Password string `env:"PASSWORD|PASS"`
+	}
+
+	type Upstream struct {
+		URL string `env:"URL"`
+	}
+
+	type Config struct {
+		Name      string              `env:"NAME"`
+		Database  Database            `env:"DATABASE"`
+		Upstreams []Upstream          `env:"UPSTREAM"`
+		Replicas  map[string]Database `env:"REPLICA"`
+	}
+
+	src := Map{
+		"NAME":             "app",
+		"OTHER":            "not under a struct prefix",
+		"DATABASE_HOST":    "localhost",
+		"DATABASE_PASWORD": "secret",
+		"DATABASE_PASS":    "secret",
+		"DATABASE_TIMEOUT": "5s",
+		"UPSTREAM_0_URL":   "http://a",
+		"UPSTREAM_0_UTL":   "http://b",
+		"REPLICA_EU_HOST":  "eu.internal",
+		"REPLICA_EU_HOTS":  "eu.internal",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	err = UnmarshalFrom(src, &cfg, WithStrict())
+	want := "unused environment variable DATABASE_PASWORD, did you mean DATABASE_PASSWORD?\n" +
+		"unused environment variable DATABASE_TIMEOUT\n" +
+		"unused environment variable REPLICA_EU_HOTS, did you mean REPLICA_EU_HOST?\n" +
+		"unused environment variable UPSTREAM_0_UTL, did you mean UPSTREAM_0_URL?"
+	assertEqual(t, want, err.Error(), "strict")
+	assertEqual(t, "localhost", cfg.Database.Host, "Database.Host")
+
+	var unused *UnusedVariableError
+	if !errors.As(err, &unused) {
+		t.Fatalf("expected *UnusedVariableError, got %v", err)
+	}
+	assertEqual(t, "DATABASE_PASWORD", unused.Key, "Key")
+	assertEqual(t, "DATABASE_PASSWORD", unused.Suggestion, "Suggestion")
+}
+
+func TestUnmarshalStrictPrefix(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT"`
+	}
+
+	src := Map{"APP_PORT": "8080", "APP_PROT": "80", "PORT": "1"}
+
+	dec, err := NewDecoder[Config](WithSource(src), WithPrefix("APP_"), WithStrict())
+	assertNoError(t, err, "NewDecoder")
+
+	cfg, err := dec.Decode()
+	assertEqual(t, "unused environment variable APP_PROT, did you mean APP_PORT?", err.Error(), "Decode")
+	assertEqual(t, 8080, cfg.Port, "Port")
+
+	dec, err = NewDecoder[Config](WithSource(src), WithPrefix("APP_"))
+	assertNoError(t, err, "NewDecoder")
+	_, err = dec.Decode()
+	assertNoError(t, err, "Decode without strict")
+}
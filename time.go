@@ -0,0 +1,131 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	locationType = reflect.TypeOf((*time.Location)(nil))
+)
+
+// timeLayouts maps the names of the layouts defined by the time package to
+// their values, so they can be referenced by name in the `layout` tag option.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+// parseTime parses a time using the given layout, or RFC 3339 if empty. The
+// layout may also be the name of one of the layouts defined by the time
+// package, such as "DateOnly".
+func parseTime(value, layout string) (time.Time, error) {
+	return time.Parse(timeLayout(layout, time.RFC3339), value)
+}
+
+// formatTime formats a time using the given layout, or RFC 3339 with
+// fractional seconds if empty, so that it parses back to the same instant.
+func formatTime(t time.Time, layout string) string {
+	return t.Format(timeLayout(layout, time.RFC3339Nano))
+}
+
+// timeLayout resolves the name of a time package layout, or returns def if
+// the layout is empty.
+func timeLayout(layout, def string) string {
+	if layout == "" {
+		return def
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// parseDuration parses a duration string in the format accepted by
+// time.ParseDuration, extended with "d" for days and "w" for weeks, e.g.
+// "1w2d12h".
+func parseDuration(value string) (time.Duration, error) {
+	s := value
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] == '.' || '0' <= s[i] && s[i] <= '9') {
+			i++
+		}
+		j := i
+		for j < len(s) && s[j] != '.' && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		number, unit := s[:i], s[i:j]
+		if number == "" {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+
+		var d time.Duration
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			scale := 24 * time.Hour
+			if unit == "w" {
+				scale *= 7
+			}
+			f := n * float64(scale)
+			if f > math.MaxInt64 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			d = time.Duration(f)
+		default:
+			var err error
+			if d, err = time.ParseDuration(number + unit); err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+		}
+
+		if total > math.MaxInt64-d {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += d
+		s = s[j:]
+	}
+
+	if neg {
+		return -total, nil
+	}
+	return total, nil
+}
@@ -0,0 +1,120 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		err      bool
+	}{
+		{"0", 0, false},
+		{"30s", 30 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"1.5h", 90 * time.Minute, false},
+		{"300ms", 300 * time.Millisecond, false},
+		{"2d", 48 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"1w2d3h", 9*24*time.Hour + 3*time.Hour, false},
+		{"0.5d", 12 * time.Hour, false},
+		{"-1d12h", -36 * time.Hour, false},
+		{"+1m", time.Minute, false},
+		{"30", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{"d", 0, true},
+		{"1y", 0, true},
+		{"1.2.3d", 0, true},
+		{"100000w", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := parseDuration(tt.input)
+			if tt.err {
+				assertError(t, err, "parseDuration")
+			} else {
+				assertNoError(t, err, "parseDuration")
+				assertEqual(t, tt.expected, result, "parseDuration")
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	result, err := parseTime("2024-06-01T12:30:00Z", "")
+	assertNoError(t, err, "parseTime default layout")
+	assertEqual(t, expected, result, "parseTime default layout")
+
+	result, err = parseTime("2024-06-01 12:30:00", "DateTime")
+	assertNoError(t, err, "parseTime named layout")
+	assertEqual(t, expected, result, "parseTime named layout")
+
+	result, err = parseTime("01/06/2024", "02/01/2006")
+	assertNoError(t, err, "parseTime custom layout")
+	assertEqual(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), result, "parseTime custom layout")
+
+	_, err = parseTime("yesterday", "")
+	assertError(t, err, "parseTime invalid")
+}
+
+func TestUnmarshalTime(t *testing.T) {
+	type Config struct {
+		Timeout   time.Duration  `env:"TIMEOUT,default=30s"`
+		Retention time.Duration  `env:"RETENTION"`
+		Start     time.Time      `env:"START"`
+		Date      time.Time      `env:"DATE,layout=DateOnly"`
+		Expires   time.Time      `env:"EXPIRES,layout=[Mon, 02 Jan 2006 15:04:05 MST]"`
+		Zone      *time.Location `env:"ZONE"`
+	}
+
+	src := Map{
+		"RETENTION": "2w",
+		"START":     "2024-06-01T12:30:00Z",
+		"DATE":      "2024-06-01",
+		"EXPIRES":   "Sat, 01 Jun 2024 12:30:00 UTC",
+		"ZONE":      "America/New_York",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, 30*time.Second, cfg.Timeout, "Timeout")
+	assertEqual(t, 14*24*time.Hour, cfg.Retention, "Retention")
+	assertEqual(t, time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC), cfg.Start, "Start")
+	assertEqual(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), cfg.Date, "Date")
+	assertEqual(t, "2024-06-01T12:30:00Z", cfg.Expires.UTC().Format(time.RFC3339), "Expires")
+	if cfg.Zone == nil || cfg.Zone.String() != "America/New_York" {
+		t.Errorf("expected zone America/New_York, got %v", cfg.Zone)
+	}
+}
+
+func TestUnmarshalTimeErrors(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration  `env:"TIMEOUT"`
+		Start   time.Time      `env:"START"`
+		Zone    *time.Location `env:"ZONE"`
+	}
+
+	src := Map{
+		"TIMEOUT": "30",
+		"START":   "yesterday",
+		"ZONE":    "Nowhere/Special",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertError(t, err, "UnmarshalFrom")
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected joined error, got %T", err)
+	}
+	assertEqual(t, 3, len(joined.Unwrap()), "number of errors")
+}
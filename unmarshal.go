@@ -1,43 +1,137 @@
 package env
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Unmarshal reads environment variables into a struct based on `env` tags.
-func Unmarshal(data interface{}) error {
-	return unmarshalWithPrefix(data, "")
+func Unmarshal(data interface{}, opts ...Option) error {
+	return newDecoder(opts).unmarshal(data)
 }
 
-// unmarshalWithPrefix unmarshals environment variables into a struct with a given prefix.
-func unmarshalWithPrefix(data interface{}, prefix string) error {
+// UnmarshalFrom reads variables from the given source into a struct based on
+// `env` tags.
+func UnmarshalFrom(src Source, data interface{}, opts ...Option) error {
+	return Unmarshal(data, append(opts, WithSource(src))...)
+}
+
+// UnmarshalWithReport reads environment variables into a struct like
+// Unmarshal, and returns a report of where each field's value came from.
+func UnmarshalWithReport(data interface{}, opts ...Option) (*Report, error) {
+	d := newDecoder(opts)
+	d.report = &Report{}
+	err := d.unmarshal(data)
+	return d.report, err
+}
+
+// decoder holds the configuration and state of a single unmarshal or marshal.
+type decoder struct {
+	src           Source
+	tagName       string
+	prefix        string
+	sep           string
+	report        *Report
+	failFast      bool
+	strict        bool
+	validated     bool // whether the struct type was validated in advance
+	intLiterals   bool
+	expandAllow   map[string]struct{} // variables expansion may reference, if restricted
+	revealSecrets bool
+	parsers       map[reflect.Type]parserFunc
+	errs          []error
+	consumed      map[string]struct{}                   // keys of the fields, in strict mode
+	prefixes      map[string]struct{}                   // prefixes of the structs, in strict mode
+	scoped        bool                                  // whether scopes are tracked, for structs that expand fields
+	refTables     map[reflect.Type]map[string]*fieldRef // referenceable fields, with parsers of its own
+	resolved      map[string]resolvedRef                // values of referenced fields by prefixed key
+}
+
+func newDecoder(opts []Option) *decoder {
+	d := &decoder{src: OS, tagName: "env"}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *decoder) unmarshal(data interface{}) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(data)}
+	}
+
+	if d.strict && !d.validated {
+		if err := d.validate(rv.Elem().Type()); err != nil {
+			return err
+		}
+	}
+
+	d.scoped = d.expands(rv.Elem().Type())
+	if err := d.unmarshalWithPrefix(data, d.prefix, "", nil); err != nil {
+		return err
+	}
+	for _, err := range d.unused() {
+		if err := d.fail(err); err != nil {
+			return err
+		}
+	}
+	return errors.Join(d.errs...)
+}
+
+// fail records an error for a field. It returns the error to stop at once
+// when failing fast, and nil otherwise so the remaining fields are still
+// unmarshalled and all errors reported together.
+func (d *decoder) fail(err error) error {
+	if d.failFast {
+		return err
+	}
+	d.errs = append(d.errs, err)
+	return nil
+}
+
+// unmarshalWithPrefix unmarshals environment variables into a struct with a
+// given prefix, nested within the struct of scope parent, if any.
+func (d *decoder) unmarshalWithPrefix(data interface{}, prefix, path string, parent *scope) error {
 	v := reflect.ValueOf(data).Elem()
-	t := v.Type()
+	d.addPrefix(prefix)
+
+	var s *scope
+	if d.scoped {
+		s = &scope{prefix: prefix, path: path, t: v.Type(), parent: parent}
+	}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
-		tag := fieldType.Tag.Get("env")
+	for _, fp := range d.plan(v.Type()).fields {
+		field := v.Field(fp.index)
+		fieldPath := path + fp.name
 
-		// Handle nested structs with optional prefixes
-		if field.Kind() == reflect.Struct {
-			if err := unmarshalStruct(field.Addr().Interface(), prefix, tag); err != nil {
+		var err error
+		switch d.kind(fp, field.Type()) {
+		case fieldNested:
+			// Handle nested structs with optional prefixes
+			if err := d.unmarshalStruct(field.Addr().Interface(), prefix, fp.tag, fieldPath+".", s); err != nil {
 				return err
 			}
 			continue
+		case fieldStructSlice:
+			err = d.unmarshalStructSlice(field, fp.opts, prefix, fieldPath, s)
+		case fieldStructMap:
+			err = d.unmarshalStructMap(field, fp.opts, prefix, fieldPath, s)
+		case fieldValue:
+			err = d.unmarshalField(field, fp.opts, prefix, fieldPath, s)
 		}
-
-		if tag == "" {
-			continue
-		}
-
-		if err := unmarshalField(field, tag, prefix, data); err != nil {
-			return err
+		if err != nil {
+			if err := d.fail(err); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -45,98 +139,297 @@ func unmarshalWithPrefix(data interface{}, prefix string) error {
 }
 
 // unmarshalStruct handles unmarshaling nested structs
-func unmarshalStruct(data interface{}, prefix, tag string) error {
+func (d *decoder) unmarshalStruct(data interface{}, prefix, tag, path string, s *scope) error {
 	newPrefix := prefix
 	if tag != "" {
 		newPrefix = prefix + tag + "_"
 	}
-	return unmarshalWithPrefix(data, newPrefix)
+	return d.unmarshalWithPrefix(data, newPrefix, path, s)
 }
 
-// unmarshalField handles unmarshaling individual fields based on tags
-func unmarshalField(field reflect.Value, tag string, prefix string, structPtr interface{}) error {
-	tagOpts := parseTag(tag)
-	value, found := findFieldValue(tagOpts.keys, prefix)
+// isStructSlice reports whether a field of the given type is a slice of
+// nested structs, or of pointers to them, rather than a list of values.
+func (d *decoder) isStructSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return isNestedStruct(elem) && d.parser(t.Elem()) == nil && d.parser(elem) == nil
+}
 
-	if tagOpts.file && found {
-		fileContent, err := readFileContent(value)
-		if err != nil {
-			return err
+// unmarshalStructSlice unmarshals a slice of structs from indexed variables,
+// such as UPSTREAM_0_HOST and UPSTREAM_1_HOST for a field tagged UPSTREAM.
+// Elements are ordered by index, and indexes must be contiguous from 0. When
+// no indexed variables are found the field is left untouched, unless it is
+// required.
+func (d *decoder) unmarshalStructSlice(field reflect.Value, tagOpts tagOptions, prefix, path string, s *scope) error {
+	var base string
+	var indexes []int
+	for _, key := range tagOpts.keys {
+		base = prefix + key + "_"
+		d.addPrefix(base)
+		if indexes = findIndexes(d.src, base); len(indexes) > 0 {
+			break
 		}
-		value = fileContent
-		found = true
 	}
 
-	if !found && tagOpts.fallback != "" {
-		value = tagOpts.fallback
+	if len(indexes) == 0 {
+		if tagOpts.required {
+			return &RequiredError{Key: prefix + tagOpts.keys[0], FieldPath: path}
+		}
+		return nil
 	}
 
-	if tagOpts.expand {
-		value = expandVariables(value, structPtr)
+	for i, index := range indexes {
+		if index != i {
+			return &IndexError{Key: base + strconv.Itoa(i), FieldPath: path, Found: base + strconv.Itoa(index)}
+		}
 	}
 
-	if tagOpts.required && value == "" {
-		return fmt.Errorf("required environment variable %s is not set", tagOpts.keys[0])
+	sliceType := field.Type()
+	slice := reflect.MakeSlice(sliceType, len(indexes), len(indexes))
+	for i := range indexes {
+		elem := slice.Index(i)
+		if i < field.Len() {
+			elem.Set(field.Index(i))
+		}
+		if elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				elem.Set(reflect.New(sliceType.Elem().Elem()))
+			}
+			elem = elem.Elem()
+		}
+		elemPrefix := base + strconv.Itoa(i) + "_"
+		elemPath := path + "[" + strconv.Itoa(i) + "]."
+		if err := d.unmarshalWithPrefix(elem.Addr().Interface(), elemPrefix, elemPath, s); err != nil {
+			return err
+		}
 	}
+	field.Set(slice)
+	return nil
+}
 
-	if found || value != "" {
-		return setField(field, value)
+// findIndexes returns the sorted, distinct indexes of the variables in the
+// source named prefix, followed by an index and an underscore.
+func findIndexes(src Source, prefix string) []int {
+	seen := make(map[int]struct{})
+	var indexes []int
+	for _, key := range src.Keys() {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		index, _, ok := cutIndex(rest)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[index]; !dup {
+			seen[index] = struct{}{}
+			indexes = append(indexes, index)
+		}
 	}
+	sort.Ints(indexes)
+	return indexes
+}
 
-	return nil
+// cutIndex splits a key following the prefix of a struct slice into the
+// index it starts with and the key of the element's field after it.
+func cutIndex(key string) (index int, rest string, ok bool) {
+	segment, rest, ok := strings.Cut(key, "_")
+	if !ok {
+		return 0, "", false
+	}
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || strconv.Itoa(index) != segment {
+		return 0, "", false
+	}
+	return index, rest, true
 }
 
-var expandRe = regexp.MustCompile(`\$\{([^}]+)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+// isStructMap reports whether a field of the given type is a map of nested
+// structs, or of pointers to them, rather than a map of values.
+func (d *decoder) isStructMap(t reflect.Type) bool {
+	if t.Kind() != reflect.Map {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return isNestedStruct(elem) && d.parser(t.Elem()) == nil && d.parser(elem) == nil
+}
 
-// expandVariables replaces placeholders with actual environment variable values or defaults if not set.
-func expandVariables(value string, structPtr interface{}) string {
-	// Handle both ${var} and $var syntax
-	matches := expandRe.FindAllStringSubmatch(value, -1)
+// unmarshalStructMap unmarshals a map of structs from named variables, such
+// as DB_PRIMARY_HOST and DB_REPLICA_HOST for a field tagged DB, where each
+// name between the prefix and one of the element struct's keys becomes a map
+// key. When no named variables are found the field is left untouched, unless
+// it is required.
+func (d *decoder) unmarshalStructMap(field reflect.Value, tagOpts tagOptions, prefix, path string, s *scope) error {
+	mapType := field.Type()
+	elemType := mapType.Elem()
+	structType := structElemType(mapType)
+	suffixes := d.structKeys(structType, "")
+
+	var base string
+	var names []string
+	for _, key := range tagOpts.keys {
+		base = prefix + key + "_"
+		d.addPrefix(base)
+		if names = findNames(d.src, base, suffixes); len(names) > 0 {
+			break
+		}
+	}
+
+	if len(names) == 0 {
+		if tagOpts.required {
+			return &RequiredError{Key: prefix + tagOpts.keys[0], FieldPath: path}
+		}
+		return nil
+	}
+
+	m := reflect.MakeMapWithSize(mapType, len(names))
+	iter := field.MapRange()
+	for iter.Next() {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+
+	for _, name := range names {
+		key := reflect.New(mapType.Key()).Elem()
+		if err := d.setValue(key, name, tagOpts); err != nil {
+			return &ParseError{Key: base + name, FieldPath: path, Type: mapType.Key(), Value: name, Err: fmt.Errorf("invalid map key %q: %w", name, err)}
+		}
+
+		elem := reflect.New(elemType).Elem()
+		if existing := m.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		target := elem
+		if target.Kind() == reflect.Pointer {
+			if target.IsNil() {
+				target.Set(reflect.New(structType))
+			}
+			target = target.Elem()
+		}
+		elemPath := path + "[" + name + "]."
+		if err := d.unmarshalWithPrefix(target.Addr().Interface(), base+name+"_", elemPath, s); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, elem)
+	}
+	field.Set(m)
+	return nil
+}
 
-	for _, match := range matches {
-		var envVar string
-		if match[1] != "" {
-			envVar = match[1] // ${var} syntax
-		} else {
-			envVar = match[2] // $var syntax
+// structKeys returns the keys, including aliases, of every field of the given
+// struct type and its nested structs, each preceded by prefix.
+func (d *decoder) structKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for _, fp := range d.plan(t).fields {
+		fieldType := t.Field(fp.index).Type
+		switch d.kind(fp, fieldType) {
+		case fieldSkip:
+		case fieldNested:
+			nestedPrefix := prefix
+			if fp.tag != "" {
+				nestedPrefix = prefix + fp.tag + "_"
+			}
+			keys = append(keys, d.structKeys(fieldType, nestedPrefix)...)
+		default:
+			for _, key := range fp.opts.keys {
+				keys = append(keys, prefix+key)
+			}
 		}
+	}
+	return keys
+}
 
-		envValue, ok := Lookup(envVar) // Lookup the environment variable; use default if not set
+// findNames returns the sorted, distinct names of the variables in the source
+// named prefix, followed by a name, an underscore and one of the suffixes.
+// Longer suffixes are matched first, so that a name never swallows part of a
+// key such as TLS_CERT when CERT is also a key.
+func findNames(src Source, prefix string, suffixes []string) []string {
+	sorted := longestFirst(suffixes)
+
+	seen := make(map[string]struct{})
+	var names []string
+	for _, key := range src.Keys() {
+		rest, ok := strings.CutPrefix(key, prefix)
 		if !ok {
-			envValue = getDefaultFromStruct(envVar, structPtr)
+			continue
+		}
+		name, _, ok := cutName(rest, sorted)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[name]; !dup {
+			seen[name] = struct{}{}
+			names = append(names, name)
 		}
-		value = strings.ReplaceAll(value, match[0], envValue)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	return value
+// longestFirst returns a copy of the suffixes sorted from longest to
+// shortest, in the order cutName matches them.
+func longestFirst(suffixes []string) []string {
+	sorted := append([]string(nil), suffixes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	return sorted
 }
 
-// getDefaultFromStruct retrieves the default value from the struct if available
-func getDefaultFromStruct(fieldName string, structPtr interface{}) string {
-	v := reflect.ValueOf(structPtr).Elem()
-	t := v.Type()
+// cutName splits a key following the prefix of a struct map into the name it
+// starts with and the first of the sorted suffixes it ends with.
+func cutName(key string, sorted []string) (name, suffix string, ok bool) {
+	for _, suffix := range sorted {
+		if name, ok := strings.CutSuffix(key, "_"+suffix); ok && name != "" {
+			return name, suffix, true
+		}
+	}
+	return "", "", false
+}
 
-	for i := 0; i < v.NumField(); i++ {
-		fieldType := t.Field(i)
-		tag := fieldType.Tag.Get("env")
-		tagOpts := parseTag(tag)
+// unmarshalField handles unmarshaling individual fields based on tags
+func (d *decoder) unmarshalField(field reflect.Value, tagOpts tagOptions, prefix, path string, s *scope) error {
+	if tagOpts.sep == "" {
+		tagOpts.sep = d.sep
+	}
+	d.consume(prefix, tagOpts.keys)
 
-		if tagOpts.keys[0] == fieldName {
-			if tagOpts.fallback != "" {
-				return tagOpts.fallback
-			}
+	entry := FieldReport{Path: path, Secret: tagOpts.secret}
+	value, found, err := d.lookupField(&entry, tagOpts, prefix)
+	if err != nil {
+		return err
+	}
+
+	if tagOpts.expand {
+		expanded, err := d.expandField(value, prefix+tagOpts.keys[0], tagOpts.secret, s, nil)
+		if err != nil {
+			return parseError(entry.Key, path, field.Type(), value, tagOpts.secret, err)
 		}
-		// Handle nested structs
-		if fieldType.Type.Kind() == reflect.Struct {
-			nestedStructPtr := v.Field(i).Addr().Interface()
-			nestedValue := getDefaultFromStruct(fieldName, nestedStructPtr)
-			if nestedValue != "" {
-				return nestedValue
-			}
+		entry.Expanded = expanded != value
+		value = expanded
+	}
+
+	if tagOpts.required && value == "" {
+		return &RequiredError{Key: prefix + tagOpts.keys[0], FieldPath: path}
+	}
+
+	if found || value != "" {
+		if err := d.setField(field, value, tagOpts); err != nil {
+			return parseError(entry.Key, path, field.Type(), value, tagOpts.secret, err)
 		}
 	}
 
-	return ""
+	if entry.Origin == OriginUnset && !isZeroValue(field) {
+		entry.Origin = OriginStruct
+	}
+	d.report.add(entry)
+
+	return nil
 }
 
 // Helper function to read file content
@@ -148,15 +441,27 @@ func readFileContent(filePath string) (string, error) {
 	return string(content), nil
 }
 
-// findFieldValue tries to find environment variable value based on keys
-func findFieldValue(keys []string, prefix string) (string, bool) {
+// findFieldValue tries to find environment variable value based on keys, and
+// returns the prefixed key that matched. For layered sources, every key is
+// tried against a layer before moving on to the next one, so that a higher
+// layer always wins regardless of which alias it sets.
+func findFieldValue(src Source, keys []string, prefix string) (string, string, bool) {
+	if layered, ok := src.(*LayeredSource); ok {
+		for i := len(layered.layers) - 1; i >= 0; i-- {
+			if key, val, ok := findFieldValue(layered.layers[i], keys, prefix); ok {
+				return key, val, true
+			}
+		}
+		return "", "", false
+	}
+
 	for _, key := range keys {
 		fullKey := prefix + key
-		if val, ok := Lookup(fullKey); ok {
-			return val, true
+		if val, ok := src.Lookup(fullKey); ok {
+			return fullKey, val, true
 		}
 	}
-	return "", false
+	return "", "", false
 }
 
 // tagOptions holds parsed tag options
@@ -166,16 +471,35 @@ type tagOptions struct {
 	required bool
 	file     bool
 	expand   bool
+	layout   string
+	sep      string
+	kvsep    string
+	desc     string
+	secret   bool
+	enum     []string
+	unknown  []string // options that aren't recognized
+}
+
+// separator returns the separator between slice elements and map entries.
+func (o tagOptions) separator() string {
+	if o.sep == "" {
+		return ","
+	}
+	return o.sep
+}
+
+// keyValueSeparator returns the separator between map keys and values.
+func (o tagOptions) keyValueSeparator() string {
+	if o.kvsep == "" {
+		return ":"
+	}
+	return o.kvsep
 }
 
 // parseTag parses the struct tag into tagOptions
 func parseTag(tag string) tagOptions {
 	parts := strings.SplitN(tag, ",", 2)
-	keys := strings.Split(parts[0], "|")
-	var fallbackValue string
-	required := false
-	file := false
-	expand := false
+	opts := tagOptions{keys: strings.Split(parts[0], "|")}
 
 	if len(parts) > 1 {
 		extraParts := parts[1]
@@ -191,21 +515,15 @@ func parseTag(tag string) tagOptions {
 				if !inBrackets {
 					part := extraParts[start:i]
 					start = i + 1
-					parsePart(part, &fallbackValue, &required, &file, &expand)
+					parsePart(part, &opts)
 				}
 			}
 		}
 		part := extraParts[start:]
-		parsePart(part, &fallbackValue, &required, &file, &expand)
+		parsePart(part, &opts)
 	}
 
-	return tagOptions{
-		keys:     keys,
-		fallback: fallbackValue,
-		required: required,
-		file:     file,
-		expand:   expand,
-	}
+	return opts
 }
 
 var (
@@ -213,97 +531,260 @@ var (
 	partSquareRe = regexp.MustCompile(`(?:default|fallback)=\[(.*?)]`)
 )
 
-func parsePart(part string, fallbackValue *string, required *bool, file *bool, expand *bool) {
-	if strings.Contains(part, "default=[") || strings.Contains(part, "fallback=[") {
+func parsePart(part string, opts *tagOptions) {
+	if value, ok := optionValue(part, "desc"); ok {
+		opts.desc = value
+	} else if strings.Contains(part, "default=[") || strings.Contains(part, "fallback=[") {
 		matches := partSquareRe.FindStringSubmatch(part)
 		if len(matches) > 1 {
-			*fallbackValue = matches[1]
+			opts.fallback = matches[1]
 		}
 	} else if strings.Contains(part, "default=") || strings.Contains(part, "fallback=") {
 		matches := partRe.FindStringSubmatch(part)
 		if len(matches) > 1 {
-			*fallbackValue = matches[1]
+			opts.fallback = matches[1]
 		}
 	} else if strings.TrimSpace(part) == "required" {
-		*required = true
+		opts.required = true
 	} else if strings.TrimSpace(part) == "file" {
-		*file = true
+		opts.file = true
 	} else if strings.TrimSpace(part) == "expand" {
-		*expand = true
+		opts.expand = true
+	} else if strings.TrimSpace(part) == "secret" || strings.TrimSpace(part) == "sensitive" {
+		opts.secret = true
+	} else if value, ok := optionValue(part, "layout"); ok {
+		opts.layout = value
+	} else if value, ok := optionValue(part, "sep"); ok {
+		opts.sep = value
+	} else if value, ok := optionValue(part, "kvsep"); ok {
+		opts.kvsep = value
+	} else if value, ok := optionValue(part, "enum"); ok {
+		opts.enum = strings.Split(value, "|")
+	} else if strings.TrimSpace(part) != "" {
+		opts.unknown = append(opts.unknown, strings.TrimSpace(part))
+	}
+}
+
+// optionValue returns the value of a name=value tag option, with any
+// enclosing square brackets removed.
+func optionValue(part, name string) (string, bool) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(part), name+"=")
+	if !ok {
+		return "", false
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		value = value[1 : len(value)-1]
 	}
+	return value, true
 }
 
 // setField sets the value of a struct field based on its type
 func setField(field reflect.Value, value string) error {
+	return newDecoder(nil).setField(field, value, tagOptions{})
+}
+
+// setField sets the value of a struct field based on its type and tag
+// options. Empty values leave the field untouched.
+func (d *decoder) setField(field reflect.Value, value string, opts tagOptions) error {
 	if value == "" {
 		return nil
 	}
+	return d.setValue(field, value, opts)
+}
+
+// setValue parses the value into v based on its type. Unlike setField, an
+// empty value is parsed like any other, which is used for slice elements
+// and map entries.
+func (d *decoder) setValue(v reflect.Value, value string, opts tagOptions) error {
+	if fn := d.parser(v.Type()); fn != nil {
+		result, err := fn(value)
+		if err != nil {
+			return err
+		}
+		v.Set(result)
+		return nil
+	}
+
+	if isSecret(v.Type()) {
+		return d.setValue(v.Addr().Interface().(secretValue).secretValue(), value, opts)
+	}
 
-	switch field.Kind() {
+	switch v.Type() {
+	case durationType:
+		duration, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(duration))
+		return nil
+	case timeType:
+		t, err := parseTime(value, opts.layout)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case locationType:
+		loc, err := time.LoadLocation(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(loc))
+		return nil
+	}
+
+	if isUnmarshaler(v.Type()) {
+		return unmarshalValue(v, value)
+	}
+
+	switch v.Kind() {
 	case reflect.String:
-		field.SetString(value)
+		v.SetString(value)
 	case reflect.Bool:
 		boolValue, err := parseBool(value)
 		if err != nil {
 			return err
 		}
-		field.SetBool(boolValue)
+		v.SetBool(boolValue)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intValue, err := strconv.ParseInt(value, 10, 64)
+		intValue, err := strconv.ParseInt(value, d.intBase(), v.Type().Bits())
 		if err != nil {
-			return err
+			return rangeError(v.Type(), value, err)
 		}
-		field.SetInt(intValue)
+		v.SetInt(intValue)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		uintValue, err := strconv.ParseUint(value, 10, 64)
+		uintValue, err := strconv.ParseUint(value, d.intBase(), v.Type().Bits())
 		if err != nil {
-			return err
+			return rangeError(v.Type(), value, err)
 		}
-		field.SetUint(uintValue)
+		v.SetUint(uintValue)
 	case reflect.Float32, reflect.Float64:
-		floatValue, err := strconv.ParseFloat(value, 64)
+		floatValue, err := strconv.ParseFloat(value, v.Type().Bits())
 		if err != nil {
-			return err
+			return rangeError(v.Type(), value, err)
 		}
-		field.SetFloat(floatValue)
+		if v.Kind() == reflect.Float32 && !isExactFloat32(value, floatValue) {
+			return &RangeError{Type: v.Type(), Value: value, Lossy: true}
+		}
+		v.SetFloat(floatValue)
 	case reflect.Slice:
-		elemType := field.Type().Elem()
-		switch elemType.Kind() {
-		case reflect.String:
-			field.Set(reflect.ValueOf(strings.Split(value, ",")))
-		case reflect.Bool:
-			boolSlice, err := parseBoolSlice(value)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(boolSlice))
-		case reflect.Int:
-			intSlice, err := parseIntSlice(value)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(intSlice))
-		case reflect.Uint:
-			uintSlice, err := parseUintSlice(value)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(uintSlice))
-		case reflect.Float64:
-			floatSlice, err := parseFloatSlice(value)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(floatSlice))
-		default:
-			return fmt.Errorf("unsupported slice element kind %s", elemType.Kind())
+		return d.setSlice(v, value, opts)
+	case reflect.Array:
+		return d.setArray(v, value, opts)
+	case reflect.Map:
+		return d.setMap(v, value, opts)
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if err := d.setValue(ptr.Elem(), value, opts); err != nil {
+			return err
 		}
+		v.Set(ptr)
 	default:
-		return fmt.Errorf("unsupported kind %s", field.Kind())
+		return fmt.Errorf("unsupported kind %s", v.Kind())
 	}
 	return nil
 }
 
+// intBase returns the base integers are parsed in, where 0 accepts Go
+// integer literal syntax.
+func (d *decoder) intBase() int {
+	if d.intLiterals {
+		return 0
+	}
+	return 10
+}
+
+// rangeError converts a strconv range error into a RangeError for the type.
+func rangeError(t reflect.Type, value string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return &RangeError{Type: t, Value: value}
+	}
+	return err
+}
+
+// isExactFloat32 reports whether the float32 parsed from value represents it
+// as precisely as a float64 would, i.e. whether the shortest decimal form of
+// the float32 denotes the same number as the value itself.
+func isExactFloat32(value string, f float64) bool {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return true
+	}
+	f64, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	shortest, err := strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+	return err == nil && shortest == f64
+}
+
+// setSlice sets a slice field by splitting the value on the separator and
+// setting each element based on its type.
+func (d *decoder) setSlice(field reflect.Value, value string, opts tagOptions) error {
+	parts := strings.Split(value, opts.separator())
+	slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := d.setValue(slice.Index(i), part, opts); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setArray sets an array field by splitting the value on the separator,
+// which must yield exactly as many elements as the array's length.
+func (d *decoder) setArray(field reflect.Value, value string, opts tagOptions) error {
+	parts := strings.Split(value, opts.separator())
+	if len(parts) != field.Len() {
+		return fmt.Errorf("expected %d elements, got %d", field.Len(), len(parts))
+	}
+	array := reflect.New(field.Type()).Elem()
+	for i, part := range parts {
+		if err := d.setValue(array.Index(i), part, opts); err != nil {
+			return err
+		}
+	}
+	field.Set(array)
+	return nil
+}
+
+// setMap sets a map field from a list of entries, such as "env:prod,team:core",
+// setting each key and value based on its type. Empty entries are ignored.
+func (d *decoder) setMap(field reflect.Value, value string, opts tagOptions) error {
+	mapType := field.Type()
+	result := reflect.MakeMap(mapType)
+	for _, entry := range strings.Split(value, opts.separator()) {
+		if entry == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(entry, opts.keyValueSeparator())
+		if !ok {
+			return fmt.Errorf("invalid map entry %q, expected key%svalue", entry, opts.keyValueSeparator())
+		}
+		key := reflect.New(mapType.Key()).Elem()
+		if err := d.setValue(key, k, opts); err != nil {
+			return err
+		}
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := d.setValue(elem, v, opts); err != nil {
+			return err
+		}
+		result.SetMapIndex(key, elem)
+	}
+	field.Set(result)
+	return nil
+}
+
+// isNestedStruct reports whether a field of the given type holds a group of
+// nested fields, rather than a single value.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType || isUnmarshaler(t) || isSecret(t) {
+		return false
+	}
+	_, registered := parsers.Load(t)
+	return !registered
+}
+
 // isZeroValue checks if the given field has a zero value
 func isZeroValue(field reflect.Value) bool {
 	if !field.IsValid() {
@@ -1,10 +1,14 @@
 package env
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type Config struct {
@@ -556,6 +560,39 @@ func TestParseTag(t *testing.T) {
 				required: true,
 			},
 		},
+		{
+			Tag: "SEPARATORS,sep=;,kvsep==",
+			ExpectedOpts: tagOptions{
+				keys:  []string{"SEPARATORS"},
+				sep:   ";",
+				kvsep: "=",
+			},
+		},
+		{
+			Tag: "LAYOUT,layout=[Mon, 02 Jan 2006],default=[Sat, 01 Jun 2024]",
+			ExpectedOpts: tagOptions{
+				keys:     []string{"LAYOUT"},
+				fallback: "Sat, 01 Jun 2024",
+				layout:   "Mon, 02 Jan 2006",
+			},
+		},
+		{
+			Tag: "PASSWORD,secret,desc=[Database password, see default=x in the docs],required",
+			ExpectedOpts: tagOptions{
+				keys:     []string{"PASSWORD"},
+				required: true,
+				secret:   true,
+				desc:     "Database password, see default=x in the docs",
+			},
+		},
+		{
+			Tag: "LEVEL,enum=debug|info|error,default=info",
+			ExpectedOpts: tagOptions{
+				keys:     []string{"LEVEL"},
+				fallback: "info",
+				enum:     []string{"debug", "info", "error"},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -648,10 +685,21 @@ func TestUnmarshalFieldFileError(t *testing.T) {
 	err := Unmarshal(&cfg)
 	assertError(t, err, "UnmarshalFieldFileError")
 
-	expectedErrPrefix := "open /invalid/path/to/file"
+	expectedErrPrefix := "cannot read file for environment variable KEY: open /invalid/path/to/file"
 	if err != nil && !strings.HasPrefix(err.Error(), expectedErrPrefix) {
 		t.Errorf("expected error to start with %s, got %s", expectedErrPrefix, err.Error())
 	}
+
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("expected *FileError, got %v", err)
+	}
+	assertEqual(t, "KEY", fileErr.Key, "Key")
+	assertEqual(t, "Key", fileErr.FieldPath, "FieldPath")
+	assertEqual(t, "/invalid/path/to/file", fileErr.Path, "Path")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected error to match fs.ErrNotExist, got %v", err)
+	}
 }
 
 func TestReadFileContentError(t *testing.T) {
@@ -719,27 +767,30 @@ func TestUnmarshalExpandWithMissingEnv(t *testing.T) {
 	assertEqual(t, expected, cfg, "UnmarshalExpandWithMissingEnv")
 }
 
-func TestGetDefaultFromStructWithFallback(t *testing.T) {
+func TestLookupRefWithFallback(t *testing.T) {
 	type Config struct {
 		Host    string `env:"HOST,default=localhost"`
 		Port    string `env:"PORT,default=8080"`
 		Address string `env:"ADDRESS,default=${HOST}:${PORT},expand"`
 	}
 
-	var cfg Config
-	defaultHost := getDefaultFromStruct("HOST", &cfg)
-	defaultPort := getDefaultFromStruct("PORT", &cfg)
+	d := newDecoder([]Option{WithSource(Map{"PORT": "9090"})})
+	s := &scope{t: reflect.TypeOf(Config{})}
 
-	if defaultHost != "localhost" {
-		t.Errorf("expected default host to be 'localhost', got '%s'", defaultHost)
-	}
+	defaultHost, _, err := d.lookupRef("HOST", false, s, nil)
+	assertNoError(t, err, "lookupRef HOST")
+	assertEqual(t, "localhost", defaultHost, "default host")
 
-	if defaultPort != "8080" {
-		t.Errorf("expected default port to be '8080', got '%s'", defaultPort)
-	}
+	port, _, err := d.lookupRef("PORT", false, s, nil)
+	assertNoError(t, err, "lookupRef PORT")
+	assertEqual(t, "9090", port, "port from source")
+
+	address, _, err := d.lookupRef("ADDRESS", false, s, nil)
+	assertNoError(t, err, "lookupRef ADDRESS")
+	assertEqual(t, "localhost:9090", address, "expanded default")
 }
 
-func TestGetDefaultFromStructWithNestedStruct(t *testing.T) {
+func TestLookupRefWithNestedStruct(t *testing.T) {
 	type NestedConfig struct {
 		NestedField string `env:"NESTED_FIELD,default=nested_default"`
 	}
@@ -749,12 +800,21 @@ func TestGetDefaultFromStructWithNestedStruct(t *testing.T) {
 		Nested NestedConfig `env:"NESTED"`
 	}
 
-	var cfg Config
-	defaultNestedField := getDefaultFromStruct("NESTED_FIELD", &cfg)
+	d := newDecoder([]Option{WithSource(Map{})})
+	s := &scope{t: reflect.TypeOf(Config{})}
 
-	if defaultNestedField != "nested_default" {
-		t.Errorf("expected default nested field to be 'nested_default', got '%s'", defaultNestedField)
-	}
+	defaultNestedField, _, err := d.lookupRef("NESTED_NESTED_FIELD", false, s, nil)
+	assertNoError(t, err, "lookupRef from the parent")
+	assertEqual(t, "nested_default", defaultNestedField, "default nested field")
+
+	nested := &scope{prefix: "NESTED_", t: reflect.TypeOf(NestedConfig{}), parent: s}
+	defaultNestedField, _, err = d.lookupRef("NESTED_FIELD", false, nested, nil)
+	assertNoError(t, err, "lookupRef from the nested struct")
+	assertEqual(t, "nested_default", defaultNestedField, "default nested field")
+
+	defaultHost, _, err := d.lookupRef("HOST", false, nested, nil)
+	assertNoError(t, err, "lookupRef from the nested struct")
+	assertEqual(t, "localhost", defaultHost, "default host of the parent")
 }
 
 func TestExpandVariables(t *testing.T) {
@@ -777,3 +837,593 @@ func TestExpandVariables(t *testing.T) {
 
 	assertEqual(t, expected, cfg, "ExpandVariables")
 }
+
+func TestUnmarshalAggregatesErrors(t *testing.T) {
+	type DatabaseConfig struct {
+		Host     string `env:"HOST,required"`
+		Password string `env:"PASSWORD,required"`
+	}
+
+	type Config struct {
+		Port     int            `env:"PORT"`
+		Debug    bool           `env:"DEBUG"`
+		Name     string         `env:"NAME,default=app"`
+		Database DatabaseConfig `env:"DATABASE"`
+	}
+
+	src := Map{
+		"PORT":          "eighty",
+		"DEBUG":         "maybe",
+		"DATABASE_HOST": "localhost",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertError(t, err, "UnmarshalFrom")
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected joined error, got %T", err)
+	}
+	assertEqual(t, 3, len(joined.Unwrap()), "number of errors")
+
+	for _, key := range []string{"PORT", "DEBUG", "DATABASE_PASSWORD"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected error to mention %s, got %s", key, err)
+		}
+	}
+
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("expected error to wrap *strconv.NumError, got %v", err)
+	}
+
+	// Valid fields are still unmarshalled.
+	assertEqual(t, "app", cfg.Name, "Name")
+	assertEqual(t, "localhost", cfg.Database.Host, "Database.Host")
+}
+
+func TestUnmarshalFailFast(t *testing.T) {
+	type Config struct {
+		First  string `env:"FIRST,required"`
+		Second string `env:"SECOND,required"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{}, &cfg, WithFailFast())
+	assertError(t, err, "UnmarshalFrom")
+	assertEqual(t, "required environment variable FIRST is not set", err.Error(), "FailFast error")
+}
+
+func TestUnmarshalMap(t *testing.T) {
+	type Config struct {
+		Tags     map[string]string        `env:"TAGS"`
+		Limits   map[string]int           `env:"LIMITS,sep=;,kvsep=="`
+		Enabled  map[int]bool             `env:"ENABLED"`
+		Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+		Defaults map[string]string        `env:"DEFAULTS,default=[env:dev,team:core]"`
+		Empty    map[string]string        `env:"EMPTY"`
+	}
+
+	src := Map{
+		"TAGS":     "env:prod,team:core,url:http://example.com",
+		"LIMITS":   "cpu=2;memory=512",
+		"ENABLED":  "1:true,2:false,",
+		"TIMEOUTS": "read:5s,write:1m",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, map[string]string{"env": "prod", "team": "core", "url": "http://example.com"}, cfg.Tags, "Tags")
+	assertEqual(t, map[string]int{"cpu": 2, "memory": 512}, cfg.Limits, "Limits")
+	assertEqual(t, map[int]bool{1: true, 2: false}, cfg.Enabled, "Enabled")
+	assertEqual(t, map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute}, cfg.Timeouts, "Timeouts")
+	assertEqual(t, map[string]string{"env": "dev", "team": "core"}, cfg.Defaults, "Defaults")
+	assertEqual(t, map[string]string(nil), cfg.Empty, "Empty")
+}
+
+func TestUnmarshalMapErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"MissingSeparator", "cpu"},
+		{"InvalidKey", "one:1"},
+		{"InvalidValue", "1:one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Map map[int]int `env:"MAP"`
+			}
+			err := UnmarshalFrom(Map{"MAP": tt.value}, &cfg)
+			assertError(t, err, "UnmarshalFrom")
+		})
+	}
+}
+
+func TestUnmarshalSliceSeparator(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,sep=;"`
+		Ports []int    `env:"PORTS,sep=[ ]"`
+		Paths []string `env:"PATHS,sep=:,default=/usr/bin:/bin"`
+	}
+
+	src := Map{
+		"HOSTS": "a,1;b,2",
+		"PORTS": "80 443",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, []string{"a,1", "b,2"}, cfg.Hosts, "Hosts")
+	assertEqual(t, []int{80, 443}, cfg.Ports, "Ports")
+	assertEqual(t, []string{"/usr/bin", "/bin"}, cfg.Paths, "Paths")
+}
+
+type Hosts []string
+
+func TestUnmarshalSliceElementKinds(t *testing.T) {
+	type Mode string
+
+	type Config struct {
+		Int64s    []int64         `env:"INT64S"`
+		Float32s  []float32       `env:"FLOAT32S"`
+		Uint8s    []uint8         `env:"UINT8S"`
+		Durations []time.Duration `env:"DURATIONS"`
+		Modes     []Mode          `env:"MODES"`
+		Named     Hosts           `env:"NAMED"`
+		Strings   []string        `env:"STRINGS"`
+	}
+
+	src := Map{
+		"INT64S":    "-1,9223372036854775807",
+		"FLOAT32S":  "1.5,2.25",
+		"UINT8S":    "1,255",
+		"DURATIONS": "1s,2m,1d",
+		"MODES":     "standalone,cluster",
+		"NAMED":     "a,b",
+		"STRINGS":   "a,,b",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, []int64{-1, 9223372036854775807}, cfg.Int64s, "Int64s")
+	assertEqual(t, []float32{1.5, 2.25}, cfg.Float32s, "Float32s")
+	assertEqual(t, []uint8{1, 255}, cfg.Uint8s, "Uint8s")
+	assertEqual(t, []time.Duration{time.Second, 2 * time.Minute, 24 * time.Hour}, cfg.Durations, "Durations")
+	assertEqual(t, []Mode{"standalone", "cluster"}, cfg.Modes, "Modes")
+	assertEqual(t, Hosts{"a", "b"}, cfg.Named, "Named")
+	assertEqual(t, []string{"a", "", "b"}, cfg.Strings, "Strings")
+}
+
+func TestUnmarshalSliceEmptyElementError(t *testing.T) {
+	var cfg struct {
+		Ints []int `env:"INTS"`
+	}
+	err := UnmarshalFrom(Map{"INTS": "1,,3"}, &cfg)
+	assertError(t, err, "UnmarshalFrom")
+}
+
+func TestUnmarshalArray(t *testing.T) {
+	type Config struct {
+		RGB     [3]uint8         `env:"RGB"`
+		Windows [2]time.Duration `env:"WINDOWS,default=[1m,5m]"`
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(Map{"RGB": "255,128,0"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, [3]uint8{255, 128, 0}, cfg.RGB, "RGB")
+	assertEqual(t, [2]time.Duration{time.Minute, 5 * time.Minute}, cfg.Windows, "Windows")
+
+	for _, value := range []string{"255,128", "255,128,0,1", "255,128,256"} {
+		err = UnmarshalFrom(Map{"RGB": value}, &cfg)
+		assertError(t, err, "UnmarshalFrom "+value)
+	}
+}
+
+func TestUnmarshalPointers(t *testing.T) {
+	type Config struct {
+		Port     *int            `env:"PORT"`
+		Debug    *bool           `env:"DEBUG"`
+		Timeout  *time.Duration  `env:"TIMEOUT,default=5s"`
+		Name     *string         `env:"NAME"`
+		Replicas []*int          `env:"REPLICAS"`
+		Nested   **string        `env:"NESTED"`
+		Zone     *time.Location  `env:"ZONE"`
+		Level    *testLevel      `env:"LEVEL"`
+		Limits   map[string]*int `env:"LIMITS"`
+	}
+
+	src := Map{
+		"PORT":     "8080",
+		"DEBUG":    "false",
+		"REPLICAS": "1,2",
+		"NESTED":   "deep",
+		"LEVEL":    "error",
+		"LIMITS":   "cpu:2",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, 8080, *cfg.Port, "Port")
+	assertEqual(t, false, *cfg.Debug, "Debug")
+	assertEqual(t, 5*time.Second, *cfg.Timeout, "Timeout")
+	assertEqual(t, (*string)(nil), cfg.Name, "Name is not allocated")
+	assertEqual(t, 2, len(cfg.Replicas), "Replicas")
+	assertEqual(t, 2, *cfg.Replicas[1], "Replicas")
+	assertEqual(t, "deep", **cfg.Nested, "Nested")
+	assertEqual(t, (*time.Location)(nil), cfg.Zone, "Zone is not allocated")
+	assertEqual(t, testLevel(2), *cfg.Level, "Level")
+	assertEqual(t, 2, *cfg.Limits["cpu"], "Limits")
+}
+
+func TestUnmarshalIntegerOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		cfg   interface{}
+	}{
+		{"Int8", "300", &struct {
+			V int8 `env:"V"`
+		}{}},
+		{"Int16", "-40000", &struct {
+			V int16 `env:"V"`
+		}{}},
+		{"Int32", "3000000000", &struct {
+			V int32 `env:"V"`
+		}{}},
+		{"Uint8", "256", &struct {
+			V uint8 `env:"V"`
+		}{}},
+		{"Uint16Slice", "1,70000", &struct {
+			V []uint16 `env:"V"`
+		}{}},
+		{"Float32", "1e39", &struct {
+			V float32 `env:"V"`
+		}{}},
+		{"Float64", "1e400", &struct {
+			V float64 `env:"V"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalFrom(Map{"V": tt.value}, tt.cfg)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			assertEqual(t, "V", parseErr.Key, "Key")
+
+			var rangeErr *RangeError
+			if !errors.As(err, &rangeErr) {
+				t.Fatalf("expected *RangeError, got %v", err)
+			}
+			assertEqual(t, false, rangeErr.Lossy, "Lossy")
+
+			if !errors.Is(err, strconv.ErrRange) {
+				t.Errorf("expected error to match strconv.ErrRange, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalIntegerBounds(t *testing.T) {
+	var cfg struct {
+		Int8  int8  `env:"INT8"`
+		Uint8 uint8 `env:"UINT8"`
+	}
+
+	err := UnmarshalFrom(Map{"INT8": "-128", "UINT8": "255"}, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+	assertEqual(t, int8(-128), cfg.Int8, "Int8")
+	assertEqual(t, uint8(255), cfg.Uint8, "Uint8")
+}
+
+func TestUnmarshalFloat32Precision(t *testing.T) {
+	var cfg struct {
+		Float32 float32 `env:"FLOAT32"`
+	}
+
+	for _, value := range []string{"3.14", "0.1", "16777216", "1e-10", "NaN"} {
+		err := UnmarshalFrom(Map{"FLOAT32": value}, &cfg)
+		assertNoError(t, err, "UnmarshalFrom "+value)
+	}
+
+	err := UnmarshalFrom(Map{"FLOAT32": "16777217"}, &cfg)
+
+	var rangeErr *RangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("expected *RangeError, got %v", err)
+	}
+	assertEqual(t, true, rangeErr.Lossy, "Lossy")
+	assertEqual(t, "value 16777217 would lose precision as float32", rangeErr.Error(), "Error")
+}
+
+func TestUnmarshalIntegerLiterals(t *testing.T) {
+	type Config struct {
+		Hex    int    `env:"HEX"`
+		Octal  uint32 `env:"OCTAL"`
+		Binary int8   `env:"BINARY"`
+		Large  int64  `env:"LARGE"`
+	}
+
+	src := Map{
+		"HEX":    "0x1F",
+		"OCTAL":  "0o755",
+		"BINARY": "0b101",
+		"LARGE":  "1_000_000",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertError(t, err, "UnmarshalFrom without literals")
+
+	err = UnmarshalFrom(src, &cfg, WithIntegerLiterals())
+	assertNoError(t, err, "UnmarshalFrom")
+
+	expected := Config{
+		Hex:    31,
+		Octal:  0o755,
+		Binary: 5,
+		Large:  1000000,
+	}
+	assertEqual(t, expected, cfg, "IntegerLiterals")
+}
+
+func TestUnmarshalStructSlice(t *testing.T) {
+	type Upstream struct {
+		Host    string        `env:"HOST,required"`
+		Port    int           `env:"PORT,default=80"`
+		Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	}
+
+	type Config struct {
+		Upstreams []Upstream  `env:"UPSTREAM"`
+		Backups   []*Upstream `env:"BACKUP"`
+		Mirrors   []Upstream  `env:"MIRROR"`
+		Untagged  []Upstream
+	}
+
+	src := Map{
+		"UPSTREAM_0_HOST":  "a.internal",
+		"UPSTREAM_0_PORT":  "8080",
+		"UPSTREAM_1_HOST":  "b.internal",
+		"UPSTREAM_10_HOST": "k.internal",
+		"UPSTREAM_2_HOST":  "c.internal",
+		"UPSTREAM_3_HOST":  "d.internal",
+		"UPSTREAM_4_HOST":  "e.internal",
+		"UPSTREAM_5_HOST":  "f.internal",
+		"UPSTREAM_6_HOST":  "g.internal",
+		"UPSTREAM_7_HOST":  "h.internal",
+		"UPSTREAM_8_HOST":  "i.internal",
+		"UPSTREAM_9_HOST":  "j.internal",
+		"UPSTREAM_TIMEOUT": "ignored",
+		"BACKUP_0_HOST":    "backup.internal",
+	}
+
+	var cfg Config
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, 11, len(cfg.Upstreams), "Upstreams")
+	assertEqual(t, Upstream{Host: "a.internal", Port: 8080, Timeout: 5 * time.Second}, cfg.Upstreams[0], "Upstreams[0]")
+	assertEqual(t, Upstream{Host: "b.internal", Port: 80, Timeout: 5 * time.Second}, cfg.Upstreams[1], "Upstreams[1]")
+	assertEqual(t, "k.internal", cfg.Upstreams[10].Host, "Upstreams[10]")
+
+	assertEqual(t, 1, len(cfg.Backups), "Backups")
+	assertEqual(t, "backup.internal", cfg.Backups[0].Host, "Backups[0]")
+
+	assertEqual(t, []Upstream(nil), cfg.Mirrors, "Mirrors")
+	assertEqual(t, []Upstream(nil), cfg.Untagged, "Untagged")
+}
+
+func TestUnmarshalStructSliceErrors(t *testing.T) {
+	type Upstream struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT"`
+	}
+
+	t.Run("Gap", func(t *testing.T) {
+		var cfg struct {
+			Upstreams []Upstream `env:"UPSTREAM"`
+		}
+		err := UnmarshalFrom(Map{"UPSTREAM_0_HOST": "a", "UPSTREAM_2_HOST": "c"}, &cfg)
+		assertError(t, err, "UnmarshalFrom")
+		assertEqual(t, "missing UPSTREAM_1: indexed variables must be contiguous from 0, found UPSTREAM_2", err.Error(), "Gap error")
+
+		var indexErr *IndexError
+		if !errors.As(err, &indexErr) {
+			t.Fatalf("expected *IndexError, got %v", err)
+		}
+		assertEqual(t, "UPSTREAM_1", indexErr.Key, "Key")
+		assertEqual(t, "Upstreams", indexErr.FieldPath, "FieldPath")
+	})
+
+	t.Run("RequiredPerIndex", func(t *testing.T) {
+		var cfg struct {
+			Upstreams []Upstream `env:"UPSTREAM"`
+		}
+		err := UnmarshalFrom(Map{"UPSTREAM_0_HOST": "a", "UPSTREAM_1_PORT": "80", "UPSTREAM_2_PORT": "x"}, &cfg)
+
+		var requiredErr *RequiredError
+		if !errors.As(err, &requiredErr) {
+			t.Fatalf("expected *RequiredError, got %v", err)
+		}
+		assertEqual(t, "UPSTREAM_1_HOST", requiredErr.Key, "Key")
+		assertEqual(t, "Upstreams[1].Host", requiredErr.FieldPath, "FieldPath")
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected *ParseError, got %v", err)
+		}
+		assertEqual(t, "UPSTREAM_2_PORT", parseErr.Key, "Key")
+		assertEqual(t, "Upstreams[2].Port", parseErr.FieldPath, "FieldPath")
+	})
+
+	t.Run("RequiredSlice", func(t *testing.T) {
+		var cfg struct {
+			Upstreams []Upstream `env:"UPSTREAM,required"`
+		}
+		err := UnmarshalFrom(Map{}, &cfg)
+
+		var requiredErr *RequiredError
+		if !errors.As(err, &requiredErr) {
+			t.Fatalf("expected *RequiredError, got %v", err)
+		}
+		assertEqual(t, "UPSTREAM", requiredErr.Key, "Key")
+	})
+}
+
+func TestUnmarshalStructMap(t *testing.T) {
+	type TLS struct {
+		Cert string `env:"CERT"`
+		Key  string `env:"KEY"`
+	}
+
+	type Database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=5432"`
+		Cert string `env:"CERT"`
+		TLS  TLS    `env:"TLS"`
+	}
+
+	type Config struct {
+		Databases map[string]Database  `env:"DB"`
+		Caches    map[string]*Database `env:"CACHE"`
+		Shards    map[int]Database     `env:"SHARD"`
+		Missing   map[string]Database  `env:"MISSING"`
+		Existing  map[string]*Database `env:"EXISTING"`
+	}
+
+	src := Map{
+		"DB_PRIMARY_HOST":          "primary.internal",
+		"DB_PRIMARY_PORT":          "6543",
+		"DB_READ_REPLICA_HOST":     "replica.internal",
+		"DB_READ_REPLICA_CERT":     "replica.pem",
+		"DB_READ_REPLICA_TLS_CERT": "tls.pem",
+		"DB_READ_REPLICA_TLS_KEY":  "tls.key",
+		"DB_UNRELATED":             "ignored",
+		"CACHE_LOCAL_HOST":         "localhost",
+		"SHARD_1_HOST":             "one.internal",
+		"SHARD_2_HOST":             "two.internal",
+		"EXISTING_KEPT_HOST":       "kept.internal",
+	}
+
+	cfg := Config{
+		Existing: map[string]*Database{
+			"kept":  {Host: "old", Port: 1},
+			"other": {Host: "other.internal"},
+		},
+	}
+	err := UnmarshalFrom(src, &cfg)
+	assertNoError(t, err, "UnmarshalFrom")
+
+	assertEqual(t, map[string]Database{
+		"PRIMARY":      {Host: "primary.internal", Port: 6543},
+		"READ_REPLICA": {Host: "replica.internal", Port: 5432, Cert: "replica.pem", TLS: TLS{Cert: "tls.pem", Key: "tls.key"}},
+	}, cfg.Databases, "Databases")
+	assertEqual(t, Database{Host: "localhost", Port: 5432}, *cfg.Caches["LOCAL"], "Caches[LOCAL]")
+	assertEqual(t, map[int]Database{
+		1: {Host: "one.internal", Port: 5432},
+		2: {Host: "two.internal", Port: 5432},
+	}, cfg.Shards, "Shards")
+	assertEqual(t, map[string]Database(nil), cfg.Missing, "Missing")
+	assertEqual(t, "kept.internal", cfg.Existing["KEPT"].Host, "Existing[KEPT]")
+	assertEqual(t, "other.internal", cfg.Existing["other"].Host, "Existing[other]")
+}
+
+func TestUnmarshalStructMapErrors(t *testing.T) {
+	type Database struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT"`
+	}
+
+	t.Run("RequiredPerName", func(t *testing.T) {
+		var cfg struct {
+			Databases map[string]Database `env:"DB"`
+		}
+		err := UnmarshalFrom(Map{"DB_PRIMARY_PORT": "5432"}, &cfg)
+
+		var requiredErr *RequiredError
+		if !errors.As(err, &requiredErr) {
+			t.Fatalf("expected *RequiredError, got %v", err)
+		}
+		assertEqual(t, "DB_PRIMARY_HOST", requiredErr.Key, "Key")
+		assertEqual(t, "Databases[PRIMARY].Host", requiredErr.FieldPath, "FieldPath")
+	})
+
+	t.Run("RequiredMap", func(t *testing.T) {
+		var cfg struct {
+			Databases map[string]Database `env:"DB,required"`
+		}
+		err := UnmarshalFrom(Map{}, &cfg)
+
+		var requiredErr *RequiredError
+		if !errors.As(err, &requiredErr) {
+			t.Fatalf("expected *RequiredError, got %v", err)
+		}
+		assertEqual(t, "DB", requiredErr.Key, "Key")
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		var cfg struct {
+			Shards map[int]Database `env:"SHARD"`
+		}
+		err := UnmarshalFrom(Map{"SHARD_ONE_HOST": "a"}, &cfg)
+		assertError(t, err, "UnmarshalFrom")
+		assertEqual(t, true, strings.HasPrefix(err.Error(), `invalid value for environment variable SHARD_ONE: invalid map key "ONE": `), "InvalidKey error")
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("expected *ParseError, got %v", err)
+		}
+		assertEqual(t, "SHARD_ONE", parseErr.Key, "Key")
+		assertEqual(t, "Shards", parseErr.FieldPath, "FieldPath")
+		assertEqual(t, "ONE", parseErr.Value, "Value")
+	})
+}
+
+type benchmarkConfig struct {
+	Name     string        `env:"NAME|APP_NAME,default=app"`
+	Port     int           `env:"PORT,default=8080"`
+	Debug    bool          `env:"DEBUG"`
+	Timeout  time.Duration `env:"TIMEOUT,default=5s"`
+	Hosts    []string      `env:"HOSTS,default=[a,b,c]"`
+	URL      string        `env:"URL,expand,default=http://${HOST}:${DB_PORT}/${NAME}"`
+	Database struct {
+		Host     string `env:"HOST,default=localhost"`
+		Port     int    `env:"PORT,default=5432"`
+		Password string `env:"PASSWORD,required"`
+	} `env:"DB"`
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	src := Map{
+		"NAME":        "bench",
+		"DEBUG":       "true",
+		"HOSTS":       "x,y,z",
+		"DB_PASSWORD": "secret",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg benchmarkConfig
+		if err := UnmarshalFrom(src, &cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
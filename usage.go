@@ -0,0 +1,68 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage returns a table of the variables read by a struct, as written by
+// PrintUsage, or an empty string if data isn't a struct or a pointer to one.
+func Usage(data interface{}) string {
+	var b strings.Builder
+	if err := PrintUsage(&b, data); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// PrintUsage writes a table of the variables read by a struct to w, listing
+// the key, aliases, type, default, whether it is required and the description
+// of each. Defaults of secret fields are replaced by a placeholder. It can be
+// appended to the usage message of a command:
+//
+//	flag.Usage = func() {
+//		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+//		flag.PrintDefaults()
+//		env.PrintUsage(flag.CommandLine.Output(), &cfg)
+//	}
+func PrintUsage(w io.Writer, data interface{}) error {
+	t, err := structTypeOf(data)
+	if err != nil {
+		return fmt.Errorf("cannot print usage: %w", err)
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  KEY\tALIASES\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION")
+	for _, field := range newDecoder(nil).fields(t, "", "") {
+		fallback := field.opts.fallback
+		if field.opts.secret && fallback != "" {
+			fallback = secretPlaceholder
+		}
+		required := ""
+		if field.opts.required {
+			required = "yes"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+			field.key, strings.Join(field.aliases, ", "), field.typ, fallback, required, field.opts.desc)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Trim the padding tabwriter leaves before empty trailing cells.
+	var out strings.Builder
+	out.WriteString("Environment variables:\n")
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		out.WriteString(strings.TrimRight(line, " \n"))
+		out.WriteString("\n")
+	}
+	_, err = io.WriteString(w, out.String())
+	return err
+}
@@ -0,0 +1,58 @@
+package env
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+type usageTestConfig struct {
+	Port     int           `env:"PORT|HTTP_PORT,default=8080,desc=Port to listen on"`
+	Timeout  time.Duration `env:"TIMEOUT,default=5s"`
+	Hosts    []string      `env:"HOSTS"`
+	Password string        `env:"DB_PASSWORD,required,secret,default=hunter2,desc=[Database password, from the vault]"`
+	Database struct {
+		Name string `env:"NAME,required"`
+	} `env:"DB"`
+}
+
+func TestUsage(t *testing.T) {
+	want := strings.Join([]string{
+		"Environment variables:",
+		"  KEY          ALIASES    TYPE           DEFAULT   REQUIRED  DESCRIPTION",
+		"  PORT         HTTP_PORT  int            8080                Port to listen on",
+		"  TIMEOUT                 time.Duration  5s",
+		"  HOSTS                   []string",
+		"  DB_PASSWORD             string         <secret>  yes       Database password, from the vault",
+		"  DB_NAME                 string                   yes",
+		"",
+	}, "\n")
+
+	assertEqual(t, want, Usage(&usageTestConfig{}), "Usage")
+	assertEqual(t, "", Usage(42), "Usage of non-struct")
+}
+
+func TestPrintUsageFlagUsage(t *testing.T) {
+	var b strings.Builder
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(&b)
+	fs.Bool("verbose", false, "verbose output")
+	fs.Usage = func() {
+		fs.PrintDefaults()
+		_ = PrintUsage(fs.Output(), &usageTestConfig{})
+	}
+
+	err := fs.Parse([]string{"-help"})
+	assertEqual(t, flag.ErrHelp, err, "Parse")
+
+	output := b.String()
+	assertEqual(t, true, strings.HasPrefix(output, "  -verbose\n"), "flag defaults first")
+	assertEqual(t, true, strings.Contains(output, "\nEnvironment variables:\n  KEY "), "environment variables appended")
+}
+
+func TestPrintUsageInvalid(t *testing.T) {
+	err := PrintUsage(&strings.Builder{}, nil)
+	assertError(t, err, "PrintUsage")
+	assertEqual(t, "cannot print usage: expected a struct or a pointer to one, got <nil>", err.Error(), "error")
+}
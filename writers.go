@@ -0,0 +1,207 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDotenv writes the variables of a source to w as a dotenv file, with
+// values double-quoted and escaped where needed, in the format read by Parse.
+func WriteDotenv(w io.Writer, src Source) error {
+	return writeVars(w, src, isDotenvKey, func(b *bufio.Writer, key, value string) error {
+		_, err := fmt.Fprintf(b, "%s=%s\n", key, quoteDotenv(value))
+		return err
+	})
+}
+
+// WriteShell writes the variables of a source to w as a POSIX shell script
+// of export statements, which can be sourced by sh or read by Parse.
+func WriteShell(w io.Writer, src Source) error {
+	return writeVars(w, src, isShellKey, func(b *bufio.Writer, key, value string) error {
+		if strings.Contains(value, "\r\n") {
+			return fmt.Errorf("value of %s contains a CRLF line ending, which cannot be written in shell format", key)
+		}
+		_, err := fmt.Fprintf(b, "export %s=%s\n", key, quoteShell(value))
+		return err
+	})
+}
+
+// WriteSystemd writes the variables of a source to w in the format of a
+// systemd EnvironmentFile, which can also be read by Parse.
+func WriteSystemd(w io.Writer, src Source) error {
+	return writeVars(w, src, isShellKey, func(b *bufio.Writer, key, value string) error {
+		if strings.Contains(value, "\r\n") {
+			return fmt.Errorf("value of %s contains a CRLF line ending, which cannot be written in systemd format", key)
+		}
+		_, err := fmt.Fprintf(b, "%s=%s\n", key, quoteShell(value))
+		return err
+	})
+}
+
+// WriteGitHubEnv writes the variables of a source to w in the format of the
+// GitHub Actions $GITHUB_ENV file, using heredoc delimiters for values that
+// aren't safe to write as is, which can also be read by Parse.
+func WriteGitHubEnv(w io.Writer, src Source) error {
+	return writeVars(w, src, isDotenvKey, func(b *bufio.Writer, key, value string) error {
+		if isBareValue(value) {
+			_, err := fmt.Fprintf(b, "%s=%s\n", key, value)
+			return err
+		}
+		// The value is followed by a line break before the delimiter, so a
+		// trailing carriage return would be read back as a CRLF line ending.
+		if strings.Contains(value+"\n", "\r\n") {
+			return fmt.Errorf("value of %s contains a carriage return before a line break, which cannot be written in GitHub format", key)
+		}
+		delim := heredocDelimiter(value)
+		_, err := fmt.Fprintf(b, "%s<<%s\n%s\n%s\n", key, delim, value, delim)
+		return err
+	})
+}
+
+// WriteNull writes the variables of a source to w as NUL-terminated
+// KEY=VALUE entries, in the format of env -0. The output can be read back
+// with Environ(strings.Split(output, "\x00")).
+func WriteNull(w io.Writer, src Source) error {
+	return writeVars(w, src, isNullKey, func(b *bufio.Writer, key, value string) error {
+		_, err := fmt.Fprintf(b, "%s=%s\x00", key, value)
+		return err
+	})
+}
+
+// writeVars validates and writes each variable of a source in key order.
+func writeVars(w io.Writer, src Source, validKey func(string) bool, write func(b *bufio.Writer, key, value string) error) error {
+	b := bufio.NewWriter(w)
+	for _, key := range src.Keys() {
+		value, _ := src.Lookup(key)
+		if !validKey(key) {
+			return fmt.Errorf("invalid variable name %q", key)
+		}
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("value of %s contains a NUL byte", key)
+		}
+		if err := write(b, key, value); err != nil {
+			return err
+		}
+	}
+	return b.Flush()
+}
+
+// quoteDotenv returns the value as is if it is safe to write unquoted, and
+// double-quoted with escapes otherwise.
+func quoteDotenv(value string) string {
+	if isBareValue(value) {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteShell returns the value as is if it is safe to write unquoted,
+// single-quoted if it contains no single quotes, and double-quoted with the
+// escapes shared by POSIX shells, systemd and Parse otherwise. Line breaks
+// are written literally, since none of them agree on other escapes.
+func quoteShell(value string) string {
+	if isBareValue(value) {
+		return value
+	}
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// heredocDelimiter returns a delimiter that doesn't appear as a line of the
+// value.
+func heredocDelimiter(value string) string {
+	lines := strings.Split(value, "\n")
+	for n := 0; ; n++ {
+		delim := "EOF"
+		if n > 0 {
+			delim += "_" + strconv.Itoa(n)
+		}
+		conflict := false
+		for _, line := range lines {
+			if line == delim {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			return delim
+		}
+	}
+}
+
+// isBareValue reports whether a value consists only of characters that have
+// no special meaning unquoted in any of the supported formats.
+func isBareValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !isAlphaNum(c) && !strings.ContainsRune("_-./:@+,%=^", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDotenvKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c == '_' || isLetter(c) || i > 0 && (isDigit(c) || c == '.')) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+func isShellKey(key string) bool {
+	return isDotenvKey(key) && !strings.Contains(key, ".")
+}
+
+func isNullKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "=\x00")
+}
+
+func isAlphaNum(c byte) bool {
+	return isLetter(c) || isDigit(c)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
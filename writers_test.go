@@ -0,0 +1,172 @@
+package env
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var writerTestVars = Map{
+	"BARE":      "postgres://user@host:5432/db",
+	"EMPTY":     "",
+	"SPACES":    "  padded value  ",
+	"COMMENT":   "value # not a comment",
+	"HASH":      "#leading",
+	"SINGLE":    "it's",
+	"DOUBLE":    `say "hi"`,
+	"BOTH":      `it's "both"`,
+	"DOLLAR":    "$HOME ${USER} $(id) `id`",
+	"BACKSLASH": `C:\path\n\t`,
+	"MULTILINE": "line1\nline2\n\nline4\n",
+	"DELIMITER": "EOF\nEOF_1",
+	"TABS":      "a\tb",
+	"CR":        "a\rb",
+	"CR_END":    "a\r",
+	"UNICODE":   "héllo wörld ✓",
+}
+
+func TestWritersRoundTrip(t *testing.T) {
+	writers := map[string]func(*strings.Builder, Source) error{
+		"Dotenv":    func(b *strings.Builder, src Source) error { return WriteDotenv(b, src) },
+		"Shell":     func(b *strings.Builder, src Source) error { return WriteShell(b, src) },
+		"Systemd":   func(b *strings.Builder, src Source) error { return WriteSystemd(b, src) },
+		"GitHubEnv": func(b *strings.Builder, src Source) error { return WriteGitHubEnv(b, src) },
+	}
+
+	// Values each writer rejects rather than writing lossily, which are
+	// covered by TestWritersErrors.
+	rejected := map[string][]string{
+		"GitHubEnv": {"CR_END"},
+	}
+
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			want := Map{}
+			for key, value := range writerTestVars {
+				if !slices.Contains(rejected[name], key) {
+					want[key] = value
+				}
+			}
+
+			var b strings.Builder
+			err := write(&b, want)
+			assertNoError(t, err, "write")
+
+			vars, err := Parse(strings.NewReader(b.String()))
+			assertNoError(t, err, "Parse")
+			assertEqual(t, want, vars, "round trip")
+		})
+	}
+
+	t.Run("Null", func(t *testing.T) {
+		var b strings.Builder
+		err := WriteNull(&b, writerTestVars)
+		assertNoError(t, err, "WriteNull")
+
+		environ := Environ(strings.Split(b.String(), "\x00"))
+		for _, key := range writerTestVars.Keys() {
+			value, ok := environ.Lookup(key)
+			assertEqual(t, true, ok, key+" present")
+			assertEqual(t, writerTestVars[key], value, key)
+		}
+		assertEqual(t, writerTestVars.Keys(), environ.Keys(), "Keys")
+	})
+}
+
+func TestWritersOutput(t *testing.T) {
+	vars := Map{
+		"HOST":  "localhost",
+		"QUOTE": "it's",
+		"TEXT":  "a b\nc",
+	}
+
+	tests := []struct {
+		name  string
+		write func(*strings.Builder, Source) error
+		want  string
+	}{
+		{
+			name:  "Dotenv",
+			write: func(b *strings.Builder, src Source) error { return WriteDotenv(b, src) },
+			want:  "HOST=localhost\nQUOTE=\"it's\"\nTEXT=\"a b\\nc\"\n",
+		},
+		{
+			name:  "Shell",
+			write: func(b *strings.Builder, src Source) error { return WriteShell(b, src) },
+			want:  "export HOST=localhost\nexport QUOTE=\"it's\"\nexport TEXT='a b\nc'\n",
+		},
+		{
+			name:  "Systemd",
+			write: func(b *strings.Builder, src Source) error { return WriteSystemd(b, src) },
+			want:  "HOST=localhost\nQUOTE=\"it's\"\nTEXT='a b\nc'\n",
+		},
+		{
+			name:  "GitHubEnv",
+			write: func(b *strings.Builder, src Source) error { return WriteGitHubEnv(b, src) },
+			want:  "HOST=localhost\nQUOTE<<EOF\nit's\nEOF\nTEXT<<EOF\na b\nc\nEOF\n",
+		},
+		{
+			name:  "Null",
+			write: func(b *strings.Builder, src Source) error { return WriteNull(b, src) },
+			want:  "HOST=localhost\x00QUOTE=it's\x00TEXT=a b\nc\x00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			err := tt.write(&b, vars)
+			assertNoError(t, err, "write")
+			assertEqual(t, tt.want, b.String(), "output")
+		})
+	}
+}
+
+func TestWritersErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "InvalidKey",
+			err:  WriteDotenv(&strings.Builder{}, Map{"1BAD": "x"}),
+			want: `invalid variable name "1BAD"`,
+		},
+		{
+			name: "DottedShellKey",
+			err:  WriteShell(&strings.Builder{}, Map{"a.b": "x"}),
+			want: `invalid variable name "a.b"`,
+		},
+		{
+			name: "NullKey",
+			err:  WriteNull(&strings.Builder{}, Map{"A=B": "x"}),
+			want: `invalid variable name "A=B"`,
+		},
+		{
+			name: "NulByte",
+			err:  WriteSystemd(&strings.Builder{}, Map{"A": "x\x00y"}),
+			want: "value of A contains a NUL byte",
+		},
+		{
+			name: "CRLF",
+			err:  WriteShell(&strings.Builder{}, Map{"A": "x\r\ny"}),
+			want: "value of A contains a CRLF line ending, which cannot be written in shell format",
+		},
+		{
+			name: "TrailingCR",
+			err:  WriteGitHubEnv(&strings.Builder{}, Map{"A": "a\r"}),
+			want: "value of A contains a carriage return before a line break, which cannot be written in GitHub format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, tt.err, tt.name)
+			assertEqual(t, tt.want, tt.err.Error(), "error")
+		})
+	}
+
+	err := WriteDotenv(&strings.Builder{}, Map{"A": "x\r\ny"})
+	assertNoError(t, err, "WriteDotenv escapes CRLF")
+}